@@ -0,0 +1,112 @@
+package gifs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const accountEndpointPath = "/account"
+
+// Usage counts imports and the bytes of storage they take up.
+type Usage struct {
+	Imports      int64 `json:"imports"`
+	StorageBytes int64 `json:"storage_bytes"`
+}
+
+// Account describes the plan and quota of the account of an API key.
+type Account struct {
+	Plan string `json:"plan,omitempty"`
+
+	// Usage is what has been used in the current period,
+	// Remaining is what is left of it until ResetAt.
+	Usage     Usage     `json:"usage"`
+	Remaining Usage     `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// Account retrieves the plan and quota of the account of the Client's API key.
+func (g *Client) Account(ctx context.Context) (*Account, error) {
+	ctx, err := g.requireAPIKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account := new(Account)
+	if err := g.doJSON(ctx, "GET", g.endpoint(accountEndpointPath), nil, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Quota is a snapshot of the limits reported
+// by the API along with its last response.
+type Quota struct {
+	// RateLimit is the number of requests allowed per window
+	// of which RateRemaining are left until RateReset.
+	RateLimit     int64
+	RateRemaining int64
+	RateReset     time.Time
+
+	// ImportsRemaining is the number of imports left in the
+	// plan's current period, -1 if it wasn't reported.
+	ImportsRemaining int64
+
+	// At is when the snapshot was taken, it is
+	// zero if no response has reported limits yet.
+	At time.Time
+}
+
+// LastQuota returns the limits reported by the latest response from
+// the API that carried any. Limits are per API key so when the Client's
+// Credentials provide several keys, this is the quota of whichever key
+// was used last, see QuotaFor for that of a given key.
+func (g *Client) LastQuota() Quota {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.quota
+}
+
+// QuotaFor returns the limits last reported for requests sent with
+// apiKey. It asks nothing of the Client's Credentials, so looking up
+// a quota doesn't advance RotatingKeys.
+func (g *Client) QuotaFor(apiKey string) Quota {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.quotas[apiKey]
+}
+
+// recordQuota keeps the limits reported in the headers of
+// res if any, res being the API's reply to a request with apiKey.
+func (g *Client) recordQuota(apiKey string, res *http.Response) {
+	parse := func(key string) (int64, bool) {
+		v, err := strconv.ParseInt(res.Header.Get(key), 10, 64)
+		return v, err == nil
+	}
+
+	q := Quota{ImportsRemaining: -1, At: time.Now()}
+	var found bool
+	if v, ok := parse("X-RateLimit-Limit"); ok {
+		q.RateLimit, found = v, true
+	}
+	if v, ok := parse("X-RateLimit-Remaining"); ok {
+		q.RateRemaining, found = v, true
+	}
+	if v, ok := parse("X-RateLimit-Reset"); ok {
+		q.RateReset, found = time.Unix(v, 0), true
+	}
+	if v, ok := parse("Gifs-Quota-Remaining"); ok {
+		q.ImportsRemaining, found = v, true
+	}
+	if !found {
+		return
+	}
+
+	g.mu.Lock()
+	g.quota = q
+	if g.quotas == nil {
+		g.quotas = make(map[string]Quota)
+	}
+	g.quotas[apiKey] = q
+	g.mu.Unlock()
+}
@@ -0,0 +1,97 @@
+package gifs_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestAccount(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		res := jsonResponse(200, `{"success": {"plan": "pro",
+			"usage": {"imports": 9000, "storage_bytes": 1073741824},
+			"remaining": {"imports": 1000, "storage_bytes": 0},
+			"reset_at": "2017-06-01T00:00:00Z"}}`)
+		res.Header.Set("X-RateLimit-Limit", "100")
+		res.Header.Set("X-RateLimit-Remaining", "99")
+		res.Header.Set("X-RateLimit-Reset", "1496275200")
+		res.Header.Set("Gifs-Quota-Remaining", "1000")
+		return res, nil
+	}
+	c, _ := gifs.New(gifs.WithAPIKey("owner"), gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	if q := c.LastQuota(); !q.At.IsZero() {
+		t.Errorf("expected no quota before any request, got %+v", q)
+	}
+
+	account, err := c.Account(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Plan != "pro" || account.Usage.Imports != 9000 || account.Remaining.Imports != 1000 {
+		t.Errorf("unexpected account %+v", account)
+	}
+	if want := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC); !account.ResetAt.Equal(want) {
+		t.Errorf("ResetAt: want %v, got %v", want, account.ResetAt)
+	}
+
+	q := c.LastQuota()
+	if q.RateLimit != 100 || q.RateRemaining != 99 || q.ImportsRemaining != 1000 || q.RateReset.Unix() != 1496275200 {
+		t.Errorf("unexpected quota %+v", q)
+	}
+}
+
+func TestQuotaOnlyFromAPI(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host != "api.gifs.com" {
+			// A third party with its own rate limits.
+			res := jsonResponse(200, `{"version": "1.0", "type": "video"}`)
+			res.Header.Set("X-RateLimit-Limit", "5")
+			return res, nil
+		}
+		res := jsonResponse(200, `{"success": {}}`)
+		res.Header.Set("X-RateLimit-Limit", "100")
+		res.Header.Set("X-RateLimit-Remaining", map[string]string{"a": "10", "b": "20"}[r.Header.Get("Gifs-Api-Key")])
+		return res, nil
+	}
+	keys := gifs.NewTenantKeys(map[string]string{"acme": "a", "globex": "b"})
+	c, _ := gifs.New(gifs.WithCredentials(keys), gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	acme := gifs.ContextWithTenant(context.Background(), "acme")
+	globex := gifs.ContextWithTenant(context.Background(), "globex")
+	c.Account(acme)
+	c.Account(globex)
+	c.FetchOEmbed(context.Background(), &gifs.Response{OEmbed: "https://example.org/oembed?url=x"}, 0, 0)
+
+	if want, got := int64(100), c.LastQuota().RateLimit; want != got {
+		t.Errorf("LastQuota: want %d, got %d", want, got)
+	}
+	for key, want := range map[string]int64{"a": 10, "b": 20} {
+		if got := c.QuotaFor(key).RateRemaining; got != want {
+			t.Errorf("%s: want %d remaining, got %d", key, want, got)
+		}
+	}
+
+	// Looking up quotas doesn't use up rotating keys.
+	var used []string
+	c, _ = gifs.New(gifs.WithCredentials(gifs.NewRotatingKeys("a", "b")), gifs.WithHTTPClient(&http.Client{
+		Transport: transport(func(r *http.Request) (*http.Response, error) {
+			used = append(used, r.Header.Get("Gifs-Api-Key"))
+			return roundTrip(r)
+		}),
+	}))
+	for i := 0; i < 2; i++ {
+		c.Account(context.Background())
+		c.QuotaFor("a")
+	}
+	if want, got := "[a b]", fmt.Sprint(used); want != got {
+		t.Errorf("keys: want %s, got %s", want, got)
+	}
+	if want, got := int64(20), c.QuotaFor("b").RateRemaining; want != got {
+		t.Errorf("b: want %d remaining, got %d", want, got)
+	}
+}
@@ -0,0 +1,264 @@
+package gifs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit open, requests to the host are failing")
+
+// BreakerState is the state of the circuit breaker of a host.
+type BreakerState int
+
+const (
+	// BreakerClosed lets requests through while counting their failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests with ErrCircuitOpen without sending them.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial request through,
+	// its outcome closes the circuit or opens it again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	defaultBreakerWindow      = 30 * time.Second
+	defaultBreakerErrorRate   = 0.5
+	defaultBreakerMinRequests = 10
+	defaultBreakerCooldown    = 10 * time.Second
+
+	breakerBuckets = 10
+)
+
+// BreakerOptions configures WithCircuitBreaker, zero values take the defaults.
+type BreakerOptions struct {
+	// Window is the span over which failures are counted, 30s by default.
+	Window time.Duration
+	// ErrorRate is the ratio of failed requests in
+	// the Window that opens the circuit, 0.5 by default.
+	ErrorRate float64
+	// MinRequests is the number of requests in the Window below which
+	// the circuit stays closed whatever the ErrorRate, 10 by default.
+	MinRequests int
+	// Cooldown is how long the circuit stays open before
+	// letting a trial request through, 10s by default.
+	Cooldown time.Duration
+
+	// OnStateChange if set is called on every change of state of
+	// the circuit of a host, after the change has taken place.
+	OnStateChange func(host string, from, to BreakerState)
+}
+
+func (bo *BreakerOptions) window() time.Duration {
+	if bo.Window > 0 {
+		return bo.Window
+	}
+	return defaultBreakerWindow
+}
+
+func (bo *BreakerOptions) errorRate() float64 {
+	if bo.ErrorRate > 0 {
+		return bo.ErrorRate
+	}
+	return defaultBreakerErrorRate
+}
+
+func (bo *BreakerOptions) minRequests() int {
+	if bo.MinRequests > 0 {
+		return bo.MinRequests
+	}
+	return defaultBreakerMinRequests
+}
+
+func (bo *BreakerOptions) cooldown() time.Duration {
+	if bo.Cooldown > 0 {
+		return bo.Cooldown
+	}
+	return defaultBreakerCooldown
+}
+
+type withCircuitBreaker struct {
+	opts BreakerOptions
+}
+
+func (wb withCircuitBreaker) apply(g *Client) {
+	g.breakers = &breakers{opts: wb.opts, hosts: make(map[string]*breaker)}
+}
+
+// WithCircuitBreaker keeps a circuit breaker per host that the Client
+// sends requests to. Once too many requests to a host fail, i.e their
+// transport failed or they got a 5xx status, further requests fail
+// with ErrCircuitOpen at once until the host has had time to recover.
+// opts may be nil for the defaults.
+func WithCircuitBreaker(opts *BreakerOptions) Option {
+	wb := withCircuitBreaker{}
+	if opts != nil {
+		wb.opts = *opts
+	}
+	return wb
+}
+
+// BreakerState returns the state of the circuit of host,
+// BreakerClosed if the Client has no circuit breaker.
+func (g *Client) BreakerState(host string) BreakerState {
+	if g.breakers == nil {
+		return BreakerClosed
+	}
+	b := g.breakers.get(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+type breakers struct {
+	opts BreakerOptions
+
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+func (bs *breakers) get(host string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.hosts[host]
+	if b == nil {
+		b = &breaker{host: host, opts: &bs.opts}
+		bs.hosts[host] = b
+	}
+	return b
+}
+
+type breakerOutcome int
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	// breakerIgnored is the outcome of requests that tell nothing of
+	// the host's health, such as those cancelled by their callers.
+	// Requests that exceed their deadline are failures.
+	breakerIgnored
+)
+
+type breaker struct {
+	host string
+	opts *BreakerOptions
+
+	mu       sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	// trial is set while the trial request of a half-open circuit is in flight.
+	trial   bool
+	buckets [breakerBuckets]breakerBucket
+}
+
+// breakerBucket counts the requests that finished in a
+// slice of the window, starting at start.
+type breakerBucket struct {
+	start           time.Time
+	total, failures int
+}
+
+// allow reports whether a request may be sent to the host, if so the
+// returned func must be called with the request's outcome.
+// A nil breaker allows every request.
+func (b *breaker) allow(now time.Time) (done func(breakerOutcome), err error) {
+	if b == nil {
+		return func(breakerOutcome) {}, nil
+	}
+
+	b.mu.Lock()
+	from := b.state
+	switch {
+	case b.state == BreakerOpen && now.Sub(b.openedAt) >= b.opts.cooldown():
+		b.state = BreakerHalfOpen
+		fallthrough
+	case b.state == BreakerHalfOpen && !b.trial:
+		b.trial = true
+	case b.state != BreakerClosed:
+		b.mu.Unlock()
+		return nil, ErrCircuitOpen
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+
+	var once sync.Once
+	return func(outcome breakerOutcome) {
+		once.Do(func() { b.record(time.Now(), outcome) })
+	}, nil
+}
+
+func (b *breaker) record(now time.Time, outcome breakerOutcome) {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case BreakerHalfOpen:
+		b.trial = false
+		switch outcome {
+		case breakerSuccess:
+			b.state = BreakerClosed
+			b.buckets = [breakerBuckets]breakerBucket{}
+		case breakerFailure:
+			b.state, b.openedAt = BreakerOpen, now
+		}
+	case BreakerClosed:
+		if outcome == breakerIgnored {
+			break
+		}
+		bucket := b.bucket(now)
+		bucket.total++
+		if outcome == breakerFailure {
+			bucket.failures++
+		}
+		total, failures := b.counts(now)
+		if total >= b.opts.minRequests() && float64(failures)/float64(total) >= b.opts.errorRate() {
+			b.state, b.openedAt = BreakerOpen, now
+		}
+	}
+	// An open circuit ignores the outcomes of the requests
+	// that were sent before it opened.
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// bucket returns the bucket that now falls in, b.mu must be held.
+func (b *breaker) bucket(now time.Time) *breakerBucket {
+	width := b.opts.window() / breakerBuckets
+	start := now.Truncate(width)
+	bucket := &b.buckets[int(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// counts sums up the buckets within the window, b.mu must be held.
+func (b *breaker) counts(now time.Time) (total, failures int) {
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.opts.window() {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+	return total, failures
+}
+
+func (b *breaker) notify(from, to BreakerState) {
+	if from != to && b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.host, from, to)
+	}
+}
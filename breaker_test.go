@@ -0,0 +1,119 @@
+package gifs_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy, sent int32
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			return jsonResponse(http.StatusBadGateway, ""), nil
+		}
+		return jsonResponse(http.StatusOK, `{"success":{"duration":3}}`), nil
+	}
+
+	var mu sync.Mutex
+	var changes []string
+	c, _ := gifs.New(
+		gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}),
+		gifs.WithCircuitBreaker(&gifs.BreakerOptions{
+			MinRequests: 4,
+			Cooldown:    50 * time.Millisecond,
+			OnStateChange: func(host string, from, to gifs.BreakerState) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, fmt.Sprintf("%s: %v->%v", host, from, to))
+			},
+		}),
+	)
+	ctx := context.Background()
+	probe := func() error {
+		_, err := c.Probe(ctx, "https://example.org/a.mp4")
+		return err
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := probe(); err == nil || err == gifs.ErrCircuitOpen {
+			t.Fatalf("#%d: want the API's error, got %v", i, err)
+		}
+	}
+	if err := probe(); err != gifs.ErrCircuitOpen {
+		t.Fatalf("open: want %v, got %v", gifs.ErrCircuitOpen, err)
+	}
+	if want, got := int32(4), atomic.LoadInt32(&sent); want != got {
+		t.Errorf("sent: want %d, got %d", want, got)
+	}
+	if want, got := gifs.BreakerOpen, c.BreakerState("api.gifs.com"); want != got {
+		t.Errorf("state: want %v, got %v", want, got)
+	}
+
+	// A failed trial opens the circuit again.
+	time.Sleep(60 * time.Millisecond)
+	if err := probe(); err == nil || err == gifs.ErrCircuitOpen {
+		t.Fatalf("trial: want the API's error, got %v", err)
+	}
+	if err := probe(); err != gifs.ErrCircuitOpen {
+		t.Fatalf("reopened: want %v, got %v", gifs.ErrCircuitOpen, err)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := probe(); err != nil {
+			t.Fatalf("recovered #%d: %v", i, err)
+		}
+	}
+
+	want := fmt.Sprint([]string{
+		"api.gifs.com: closed->open",
+		"api.gifs.com: open->half-open",
+		"api.gifs.com: half-open->open",
+		"api.gifs.com: open->half-open",
+		"api.gifs.com: half-open->closed",
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if got := fmt.Sprint(changes); want != got {
+		t.Errorf("changes:\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func TestCircuitBreakerDeadlines(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}
+	c, _ := gifs.New(
+		gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}),
+		gifs.WithCircuitBreaker(&gifs.BreakerOptions{MinRequests: 2}),
+	)
+
+	// Calls cancelled by their callers don't count.
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(5*time.Millisecond, cancel)
+		c.Probe(ctx, "https://example.org/a.mp4")
+	}
+	if want, got := gifs.BreakerClosed, c.BreakerState("api.gifs.com"); want != got {
+		t.Errorf("cancelled: want %v, got %v", want, got)
+	}
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		c.Probe(ctx, "https://example.org/a.mp4")
+		cancel()
+	}
+	if want, got := gifs.BreakerOpen, c.BreakerState("api.gifs.com"); want != got {
+		t.Errorf("timed out: want %v, got %v", want, got)
+	}
+}
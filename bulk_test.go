@@ -0,0 +1,71 @@
+package gifs_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestBulkImportStopsEarly(t *testing.T) {
+	var sent int32
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "good") {
+			return jsonResponse(http.StatusOK, `{"success":{"page":"https://gifs.com/gif/Z4Wrp2"}}`), nil
+		}
+		return jsonResponse(http.StatusServiceUnavailable, `{"errors":"unavailable"}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	// The first 10 requests succeed and the remaining 40 fail.
+	requests := func() []*gifs.Request {
+		reqs := make([]*gifs.Request, 50)
+		for i := range reqs {
+			status := "bad"
+			if i < 10 {
+				status = "good"
+			}
+			reqs[i] = &gifs.Request{URL: fmt.Sprintf("https://example.org/%s/%d.mp4", status, i)}
+		}
+		return reqs
+	}
+
+	tests := []struct {
+		name string
+		bip  *gifs.BulkImportRequest
+		sent int
+	}{
+		{"all", &gifs.BulkImportRequest{}, 50},
+		{"first error", &gifs.BulkImportRequest{StopOnFirstError: true}, 11},
+		{"max failures", &gifs.BulkImportRequest{MaxFailures: 3}, 13},
+		// Stops at the 11th failure, 11/21 being over half.
+		{"failure ratio", &gifs.BulkImportRequest{MaxFailureRatio: 0.5}, 21},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&sent, 0)
+		tt.bip.ConcurrentImports = 1
+		tt.bip.Requests = requests()
+		responses, err := c.ImportBulk(tt.bip)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := int(atomic.LoadInt32(&sent)); got != tt.sent {
+			t.Errorf("%s: sent: want %d, got %d", tt.name, tt.sent, got)
+		}
+		for i, res := range responses {
+			if want := i >= tt.sent; res.Skipped != want {
+				t.Errorf("%s: #%d skipped: want %v, got %v", tt.name, i, want, res.Skipped)
+			}
+			if res.Skipped && res.Error.Message != gifs.ErrSkipped.Error() {
+				t.Errorf("%s: #%d error: want %v, got %v", tt.name, i, gifs.ErrSkipped, res.Error.Message)
+			}
+		}
+	}
+}
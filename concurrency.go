@@ -0,0 +1,89 @@
+package gifs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+type withMaxConcurrency int
+
+func (n withMaxConcurrency) apply(g *Client) {
+	g.conns = nil
+	if n > 0 {
+		g.conns = make(chan struct{}, int(n))
+	}
+}
+
+// WithMaxConcurrency caps the requests that the Client has in flight
+// at once to n, across all of its methods and callers, on top of the
+// ConcurrentImports of each ImportBulk. Requests over the cap wait
+// for a slot, see Client.Stats. n less than 1 means no cap.
+func WithMaxConcurrency(n int) Option {
+	return withMaxConcurrency(n)
+}
+
+// Stats is a snapshot of the requests of a Client.
+type Stats struct {
+	// InFlight is the number of requests being sent or
+	// whose response bodies have yet to be closed.
+	InFlight int
+	// Queued is the number of requests waiting for a
+	// slot because of WithMaxConcurrency.
+	Queued int
+	// MaxConcurrency is the cap set by WithMaxConcurrency,
+	// 0 if there is none in which case InFlight is always 0.
+	MaxConcurrency int
+}
+
+// Stats reports the requests that the Client has in flight and
+// queued, e.g for exporting the queue depth as a metric.
+func (g *Client) Stats() Stats {
+	return Stats{
+		InFlight:       len(g.conns),
+		Queued:         int(atomic.LoadInt64(&g.queued)),
+		MaxConcurrency: cap(g.conns),
+	}
+}
+
+// acquireConn waits for a slot to send a request in, the
+// returned func gives it back and is safe to call twice.
+func (g *Client) acquireConn(ctx context.Context) (release func(), err error) {
+	if g.conns == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case g.conns <- struct{}{}:
+	default:
+		atomic.AddInt64(&g.queued, 1)
+		select {
+		case g.conns <- struct{}{}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		atomic.AddInt64(&g.queued, -1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-g.conns })
+	}, nil
+}
+
+// releaseOnClose gives back the slot of a
+// response once its body has been closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (rc *releaseOnClose) Close() error {
+	err := rc.ReadCloser.Close()
+	rc.release()
+	return err
+}
@@ -0,0 +1,65 @@
+package gifs_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	proceed := make(chan bool)
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		<-proceed
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return jsonResponse(http.StatusOK, `{"success":{}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}), gifs.WithMaxConcurrency(2))
+
+	const callers, perCaller = 4, 5
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		reqs := make([]*gifs.Request, perCaller)
+		for j := range reqs {
+			reqs[j] = &gifs.Request{URL: fmt.Sprintf("https://example.org/%d-%d.mp4", i, j)}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ImportBulk(&gifs.BulkImportRequest{ConcurrentImports: perCaller, Requests: reqs})
+		}()
+	}
+
+	want := gifs.Stats{InFlight: 2, Queued: callers*perCaller - 2, MaxConcurrency: 2}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Stats() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats: want %+v, got %+v", want, c.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < callers*perCaller; i++ {
+		proceed <- true
+	}
+	wg.Wait()
+
+	if maxInFlight != 2 {
+		t.Errorf("max in flight: want 2, got %d", maxInFlight)
+	}
+	if want, got := (gifs.Stats{MaxConcurrency: 2}), c.Stats(); want != got {
+		t.Errorf("stats after: want %+v, got %+v", want, got)
+	}
+}
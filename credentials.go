@@ -0,0 +1,216 @@
+package gifs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Credentials provides the API key that a request is sent with.
+//
+// The key of a request is, in order of precedence:
+//  1. the Request's APIKey if it is set
+//  2. the key provided by the Client's Credentials, see WithCredentials
+//  3. none, the request is sent anonymously
+//
+// The key is picked once per call: all the requests that an Upload,
+// a ListMedia iteration or an import make are sent with the same key.
+// Keys are only ever sent in the Gifs-Api-Key header, or
+// not at all if the Client is created WithHMACSigning. A Client
+// prints its Credentials by their String method if they implement
+// fmt.Stringer, or else only by their type.
+type Credentials interface {
+	// APIKey returns the key to send the request with. The request
+	// is available through RequestFromContext for imports and
+	// uploads, and is absent for calls such as GetMedia.
+	// An empty key sends the request anonymously.
+	APIKey(ctx context.Context) (string, error)
+}
+
+// CredentialsFunc adapts a function to Credentials.
+type CredentialsFunc func(ctx context.Context) (string, error)
+
+func (f CredentialsFunc) APIKey(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticKey is a single API key used for every request.
+type StaticKey string
+
+func (k StaticKey) APIKey(context.Context) (string, error) {
+	return string(k), nil
+}
+
+// String redacts the key, as do the String methods of
+// the other Credentials in this package.
+func (k StaticKey) String() string {
+	return "StaticKey(" + redacted + ")"
+}
+
+func (k StaticKey) GoString() string {
+	return k.String()
+}
+
+// RotatingKeys spreads calls over several API keys
+// in turn. Keys can be replaced at any time with Set.
+type RotatingKeys struct {
+	mu   sync.Mutex
+	keys []string
+	next int
+}
+
+func NewRotatingKeys(keys ...string) *RotatingKeys {
+	rk := new(RotatingKeys)
+	rk.Set(keys...)
+	return rk
+}
+
+// Set replaces the keys that are rotated through.
+func (rk *RotatingKeys) Set(keys ...string) {
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	rk.keys = append([]string(nil), keys...)
+	rk.next = 0
+}
+
+func (rk *RotatingKeys) String() string {
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	return fmt.Sprintf("RotatingKeys(%d keys)", len(rk.keys))
+}
+
+func (rk *RotatingKeys) APIKey(context.Context) (string, error) {
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	if len(rk.keys) == 0 {
+		return "", nil
+	}
+	key := rk.keys[rk.next%len(rk.keys)]
+	rk.next = (rk.next + 1) % len(rk.keys)
+	return key, nil
+}
+
+// TenantKeys picks the API key of the tenant that a request is made on
+// behalf of. The tenant is the one set by ContextWithTenant if any, or
+// else the request's CreatedFrom. Imports take no context, so their
+// tenant is their CreatedFrom. Keys can be changed at any time.
+type TenantKeys struct {
+	mu   sync.RWMutex
+	keys map[string]string
+
+	// Fallback if set provides the key of unknown tenants,
+	// otherwise their requests fail with ErrAPIKeyRequired.
+	Fallback Credentials
+}
+
+func NewTenantKeys(keys map[string]string) *TenantKeys {
+	tk := &TenantKeys{keys: make(map[string]string)}
+	for tenant, key := range keys {
+		tk.keys[tenant] = key
+	}
+	return tk
+}
+
+// Set adds or replaces the key of tenant, an empty key removes it.
+func (tk *TenantKeys) Set(tenant, key string) {
+	tk.mu.Lock()
+	defer tk.mu.Unlock()
+	if key == "" {
+		delete(tk.keys, tenant)
+	} else {
+		tk.keys[tenant] = key
+	}
+}
+
+func (tk *TenantKeys) String() string {
+	tk.mu.RLock()
+	defer tk.mu.RUnlock()
+	return fmt.Sprintf("TenantKeys(%d tenants)", len(tk.keys))
+}
+
+func (tk *TenantKeys) APIKey(ctx context.Context) (string, error) {
+	tenant := TenantFromContext(ctx)
+	tk.mu.RLock()
+	key, ok := tk.keys[tenant]
+	tk.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if tk.Fallback != nil {
+		return tk.Fallback.APIKey(ctx)
+	}
+	return "", ErrAPIKeyRequired
+}
+
+type contextKey int
+
+const (
+	requestContextKey contextKey = iota
+	tenantContextKey
+	apiKeyContextKey
+)
+
+func contextWithRequest(ctx context.Context, req *Request) context.Context {
+	return context.WithValue(ctx, requestContextKey, req)
+}
+
+// RequestFromContext returns the import or upload that ctx is for, if any.
+func RequestFromContext(ctx context.Context) (*Request, bool) {
+	req, ok := ctx.Value(requestContextKey).(*Request)
+	return req, ok && req != nil
+}
+
+// ContextWithTenant marks calls made with the returned context
+// as being on behalf of tenant, see TenantKeys.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant set by ContextWithTenant,
+// or else the CreatedFrom of the request that ctx is for.
+func TenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantContextKey).(string); ok {
+		return tenant
+	}
+	if req, ok := RequestFromContext(ctx); ok {
+		return req.CreatedFrom
+	}
+	return ""
+}
+
+// apiKeyFor returns the key to send a request made with ctx with.
+func (g *Client) apiKeyFor(ctx context.Context) (string, error) {
+	if key, ok := ctx.Value(apiKeyContextKey).(string); ok {
+		return key, nil
+	}
+	if req, ok := RequestFromContext(ctx); ok && req.APIKey != "" {
+		return req.APIKey, nil
+	}
+	if g.creds == nil {
+		return "", nil
+	}
+	return g.creds.APIKey(ctx)
+}
+
+// withAPIKey picks the key of a call once so that all the requests
+// made with the returned context are sent with it.
+func (g *Client) withAPIKey(ctx context.Context) (context.Context, string, error) {
+	key, err := g.apiKeyFor(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return context.WithValue(ctx, apiKeyContextKey, key), key, nil
+}
+
+// requireAPIKey fails unless calls made with ctx are authenticated,
+// it returns ctx with the key picked as per withAPIKey.
+func (g *Client) requireAPIKey(ctx context.Context) (context.Context, error) {
+	ctx, key, err := g.withAPIKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, ErrAPIKeyRequired
+	}
+	return ctx, nil
+}
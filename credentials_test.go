@@ -0,0 +1,144 @@
+package gifs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+// recordKeys returns an http.Client that records the API key
+// of each request by its source, along with any key in its body.
+func recordKeys(t *testing.T) (*http.Client, map[string]string, map[string]string) {
+	var mu sync.Mutex
+	headerKeys := make(map[string]string)
+	bodyKeys := make(map[string]string)
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		sent := new(struct {
+			URL    string `json:"source"`
+			APIKey string `json:"api_key"`
+		})
+		if err := json.Unmarshal(body, sent); err != nil {
+			t.Errorf("unmarshal body: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		headerKeys[sent.URL] = r.Header.Get("Gifs-Api-Key")
+		bodyKeys[sent.URL] = sent.APIKey
+		return nil, errors.New("not implemented")
+	}
+	return &http.Client{Transport: transport(roundTrip)}, headerKeys, bodyKeys
+}
+
+func TestCredentialsPrecedence(t *testing.T) {
+	hc, headerKeys, bodyKeys := recordKeys(t)
+	c, _ := gifs.New(gifs.WithAPIKey("client"), gifs.WithHTTPClient(hc))
+	c.ImportBulk(&gifs.BulkImportRequest{Requests: []*gifs.Request{
+		{URL: "default"},
+		{URL: "override", APIKey: "request"},
+	}})
+
+	if want, got := "client", headerKeys["default"]; want != got {
+		t.Errorf("default: want %q, got %q", want, got)
+	}
+	if want, got := "request", headerKeys["override"]; want != got {
+		t.Errorf("override: want %q, got %q", want, got)
+	}
+	for source, key := range bodyKeys {
+		if key != "" {
+			t.Errorf("%s: the key %q was sent in the body", source, key)
+		}
+	}
+}
+
+func TestRotatingAndTenantKeys(t *testing.T) {
+	hc, headerKeys, _ := recordKeys(t)
+	rotating := gifs.NewRotatingKeys("k1", "k2")
+	c, _ := gifs.New(gifs.WithCredentials(rotating), gifs.WithHTTPClient(hc))
+	c.ImportBulk(&gifs.BulkImportRequest{
+		ConcurrentImports: 1,
+		Requests:          []*gifs.Request{{URL: "a"}, {URL: "b"}, {URL: "c"}},
+	})
+	var used []string
+	for _, key := range headerKeys {
+		used = append(used, key)
+	}
+	sort.Strings(used)
+	if want, got := "[k1 k1 k2]", fmt.Sprint(used); want != got {
+		t.Errorf("keys: want %s, got %s", want, got)
+	}
+
+	hc, headerKeys, _ = recordKeys(t)
+	tenants := gifs.NewTenantKeys(map[string]string{"acme": "acme-key"})
+	c, _ = gifs.New(gifs.WithCredentials(tenants), gifs.WithHTTPClient(hc))
+	c.ImportBulk(&gifs.BulkImportRequest{Requests: []*gifs.Request{{URL: "acme", CreatedFrom: "acme"}}})
+	if want, got := "acme-key", headerKeys["acme"]; want != got {
+		t.Errorf("acme: want %q, got %q", want, got)
+	}
+
+	// Keys are refreshed without rebuilding the Client.
+	tenants.Set("acme", "rotated")
+	c.ImportBulk(&gifs.BulkImportRequest{Requests: []*gifs.Request{{URL: "acme", CreatedFrom: "acme"}}})
+	if want, got := "rotated", headerKeys["acme"]; want != got {
+		t.Errorf("acme: want %q, got %q", want, got)
+	}
+
+	if _, err := c.GetMedia(context.Background(), "Z4Wrp2"); err != gifs.ErrAPIKeyRequired {
+		t.Errorf("unknown tenant: want %v, got %v", gifs.ErrAPIKeyRequired, err)
+	}
+}
+
+func TestKeyPerCall(t *testing.T) {
+	var mu sync.Mutex
+	var used []string
+	fs := newFakeUploadServer()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		used = append(used, r.Header.Get("Gifs-Api-Key"))
+		mu.Unlock()
+		if r.URL.Path == "/media" {
+			reply(w, 200, map[string]interface{}{"media": []map[string]string{{"id": "a"}}, "next_cursor": r.URL.Query().Get("cursor") + "x"})
+			return
+		}
+		fs.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	rotating := gifs.NewRotatingKeys("k1", "k2", "k3")
+	c, _ := gifs.New(gifs.WithCredentials(rotating), gifs.WithBaseURL(server.URL))
+
+	req := new(gifs.Request)
+	req.SetMedia(bytes.NewReader(append([]byte("\x00\x00\x00\x18ftypisom"), "uploaded in many chunks"...)))
+	if _, err := c.Upload(context.Background(), req, &gifs.UploadOptions{ChunkSize: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "k1"; !allOf(used, want) || len(used) < 4 {
+		t.Errorf("upload: want every request sent with %s, got %v", want, used)
+	}
+
+	used = nil
+	it := c.ListMedia(context.Background(), gifs.ListOptions{})
+	for i := 0; i < 3 && it.Next(); i++ {
+	}
+	if want := "k2"; !allOf(used, want) || len(used) != 3 {
+		t.Errorf("list: want every page fetched with %s, got %v", want, used)
+	}
+}
+
+func allOf(keys []string, key string) bool {
+	for _, k := range keys {
+		if k != key {
+			return false
+		}
+	}
+	return true
+}
@@ -1,17 +1,179 @@
 package gifs
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMalformedAspect    = errors.New("malformed aspect ratio, expecting the form width:height e.g 16:9")
+	ErrCropOutOfBounds    = errors.New("crop is out of the media's bounds")
+	ErrPadOutOfBounds     = errors.New("pad is too small to hold the media")
+	ErrUnknownDimensions  = errors.New("media dimensions are unknown")
+	ErrUnresolvedRelative = errors.New("relative crop or pad must first be resolved against the source, see Request.Resolve")
+)
+
+// Unit is the unit in which the offsets and
+// dimensions of a Crop or a Pad are expressed.
+type Unit string
+
+const (
+	// UnitPixels is the default unit.
+	UnitPixels Unit = "px"
+	// UnitPercent expresses values as a percentage of
+	// the source's width (X, Width) or height (Y, Height)
+	// so that they apply to any resolution.
+	UnitPercent Unit = "percent"
+)
+
 // Crop holds the offsets and final dimensions of the
-// desired media after cropping. Both X and Y offsets 
+// desired media after cropping. Both X and Y offsets
 // will tell us where the top-left corner of the cropped
-// media will be. Then Height and Width can determine the 
-// top-right, bottom-left and bottom-right coordinates. 
+// media will be. Then Height and Width can determine the
+// top-right, bottom-left and bottom-right coordinates.
+//
+// Values are in pixels unless Unit says otherwise. If Aspect
+// is set, the largest centered area of that aspect ratio is
+// kept instead and the other fields are ignored.
+//
+// The API only takes crops in pixels, Unit and Aspect are never
+// sent to it. Requests with a relative crop have to be resolved
+// e.g with Request.Resolve, they fail to be sent otherwise.
 type Crop struct {
 	// X is the horizontal axis offset from the left
 	X float32 `json:"x,omitempty"`
-	// Y is the vertical axis offset from the top 
+	// Y is the vertical axis offset from the top
 	Y float32 `json:"y,omitempty"`
 	// Height of the desired media after cropping
 	Height float32 `json:"height,omitempty"`
 	// Width of the desired media after cropping
-	Width  float32 `json:"width,omitempty"`
+	Width float32 `json:"width,omitempty"`
+
+	Unit   Unit   `json:"unit,omitempty"`
+	Aspect string `json:"aspect,omitempty"`
+}
+
+// CropPercent crops an area whose offsets and dimensions
+// are percentages of the source's dimensions.
+func CropPercent(x, y, width, height float32) *Crop {
+	return &Crop{X: x, Y: y, Width: width, Height: height, Unit: UnitPercent}
+}
+
+// CropInset trims percent of the source's dimensions from each edge.
+func CropInset(percent float32) *Crop {
+	return CropPercent(percent, percent, 100-2*percent, 100-2*percent)
+}
+
+// CropAspect center-crops to the aspect ratio width:height
+// e.g CropAspect(1, 1) for a square.
+func CropAspect(width, height int) *Crop {
+	return &Crop{Aspect: formatAspect(width, height)}
+}
+
+// Resolve returns the crop in absolute pixels for a source of
+// the given dimensions, failing if it falls outside of the source.
+func (c *Crop) Resolve(width, height int) (*Crop, error) {
+	if c == nil {
+		return nil, ErrNilParamDereference
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrUnknownDimensions, width, height)
+	}
+	w, h := float64(width), float64(height)
+
+	var resolved Crop
+	switch {
+	case c.Aspect != "":
+		ratio, err := parseAspect(c.Aspect)
+		if err != nil {
+			return nil, err
+		}
+		cw, ch := w, h
+		if w/h > ratio {
+			cw = math.Round(h * ratio)
+		} else {
+			ch = math.Round(w / ratio)
+		}
+		resolved = Crop{
+			X:      float32(math.Floor((w - cw) / 2)),
+			Y:      float32(math.Floor((h - ch) / 2)),
+			Width:  float32(cw),
+			Height: float32(ch),
+		}
+	case c.Unit == UnitPercent:
+		resolved = Crop{
+			X:      float32(math.Round(float64(c.X) * w / 100)),
+			Y:      float32(math.Round(float64(c.Y) * h / 100)),
+			Width:  float32(math.Round(float64(c.Width) * w / 100)),
+			Height: float32(math.Round(float64(c.Height) * h / 100)),
+		}
+	case c.Unit == "" || c.Unit == UnitPixels:
+		resolved = Crop{X: c.X, Y: c.Y, Width: c.Width, Height: c.Height}
+	default:
+		return nil, fmt.Errorf("unknown unit %q", c.Unit)
+	}
+
+	if resolved.X < 0 || resolved.Y < 0 ||
+		float64(resolved.X+resolved.Width) > w || float64(resolved.Y+resolved.Height) > h {
+		return nil, fmt.Errorf("%w: %+v of %dx%d", ErrCropOutOfBounds, resolved, width, height)
+	}
+	return &resolved, nil
+}
+
+// relative reports whether the crop depends on the source's dimensions.
+func (c *Crop) relative() bool {
+	return c.Aspect != "" || (c.Unit != "" && c.Unit != UnitPixels)
+}
+
+// inPixels returns a copy of the request as sent to the API,
+// with its crop and pads in plain pixels, or ErrUnresolvedRelative
+// if any of them is still relative to the source's dimensions.
+func (p *Request) inPixels() (*Request, error) {
+	sent := *p
+	if p.Crop != nil {
+		if p.Crop.relative() {
+			return nil, ErrUnresolvedRelative
+		}
+		crop := *p.Crop
+		crop.Unit = ""
+		sent.Crop = &crop
+	}
+	if p.Effects != nil && len(p.Effects.Pad) > 0 {
+		effects := *p.Effects
+		effects.Pad = make([]*Pad, len(p.Effects.Pad))
+		for i, pad := range p.Effects.Pad {
+			if pad == nil {
+				continue
+			}
+			if pad.relative() {
+				return nil, ErrUnresolvedRelative
+			}
+			copied := *pad
+			copied.Unit = ""
+			effects.Pad[i] = &copied
+		}
+		sent.Effects = &effects
+	}
+	return &sent, nil
+}
+
+func formatAspect(width, height int) string {
+	return strconv.Itoa(width) + ":" + strconv.Itoa(height)
+}
+
+// parseAspect returns the width/height ratio of an aspect such as "16:9".
+func parseAspect(aspect string) (float64, error) {
+	parts := strings.Split(aspect, ":")
+	if len(parts) != 2 {
+		return 0, ErrMalformedAspect
+	}
+	w, errW := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	h, errH := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, ErrMalformedAspect
+	}
+	return w / h, nil
 }
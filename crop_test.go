@@ -0,0 +1,98 @@
+package gifs_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestCropResolve(t *testing.T) {
+	tests := []struct {
+		crop          *gifs.Crop
+		width, height int
+		want          gifs.Crop
+		wantErr       bool
+	}{
+		{crop: gifs.CropAspect(1, 1), width: 1920, height: 1080, want: gifs.Crop{X: 420, Width: 1080, Height: 1080}},
+		{crop: gifs.CropAspect(1, 1), width: 360, height: 640, want: gifs.Crop{Y: 140, Width: 360, Height: 360}},
+		{crop: gifs.CropInset(10), width: 640, height: 360, want: gifs.Crop{X: 64, Y: 36, Width: 512, Height: 288}},
+		{crop: &gifs.Crop{X: 40, Y: 10, Width: 200, Height: 200}, width: 640, height: 360, want: gifs.Crop{X: 40, Y: 10, Width: 200, Height: 200}},
+		{crop: &gifs.Crop{X: 400, Width: 300, Height: 200}, width: 640, height: 360, wantErr: true},
+		{crop: &gifs.Crop{Aspect: "16-9"}, width: 640, height: 360, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := tt.crop.Resolve(tt.width, tt.height)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want an error, got %+v", i, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: want nil, got err %v", i, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("#%d: want %+v, got %+v", i, tt.want, *got)
+		}
+	}
+}
+
+func TestPadResolve(t *testing.T) {
+	got, err := gifs.PadAspect(1, 1, "black").Resolve(640, 360)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gifs.Pad{Y: 140, Width: 640, Height: 640, Color: "black"}
+	if *got != want {
+		t.Errorf("want %+v, got %+v", want, *got)
+	}
+}
+
+func TestResolveRelative(t *testing.T) {
+	var body []byte
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		body, _ = ioutil.ReadAll(r.Body)
+		return jsonResponse(http.StatusOK, `{"success":{}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	req := &gifs.Request{
+		URL:     "https://example.org/a.mp4",
+		Crop:    gifs.CropInset(10),
+		Effects: &gifs.Effects{Pad: []*gifs.Pad{gifs.PadAspect(1, 1, "black")}},
+	}
+	res, err := c.Import(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := gifs.ErrUnresolvedRelative.Error(), res.Error.Message; want != got || body != nil {
+		t.Errorf("unresolved: want %q and nothing sent, got %q and %s", want, got, body)
+	}
+
+	if err := req.Resolve(&gifs.SourceInfo{Width: 640, Height: 360}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Import(req); err != nil {
+		t.Fatal(err)
+	}
+	want := `"crop":{"x":64,"y":36,"height":288,"width":512},"effects":{"pad":[{"y":112,"color":"black","height":512,"width":512}]}`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("want %s in %s", want, body)
+	}
+
+	if err := (&gifs.Request{Crop: gifs.CropInset(10)}).Resolve(new(gifs.SourceInfo)); !errors.Is(err, gifs.ErrUnknownDimensions) {
+		t.Errorf("unknown dimensions: want %v, got %v", gifs.ErrUnknownDimensions, err)
+	}
+	if _, err := (&gifs.Pad{Width: 100, Height: 100}).Resolve(640, 360); !errors.Is(err, gifs.ErrPadOutOfBounds) {
+		t.Errorf("small pad: want %v, got %v", gifs.ErrPadOutOfBounds, err)
+	}
+	if _, err := gifs.PadAspect(1, 1, "black").Resolve(0, 0); !errors.Is(err, gifs.ErrUnknownDimensions) {
+		t.Errorf("0x0 pad: want %v, got %v", gifs.ErrUnknownDimensions, err)
+	}
+}
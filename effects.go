@@ -1,5 +1,10 @@
 package gifs
 
+import (
+	"fmt"
+	"math"
+)
+
 // Effects define the collection of alterations
 // that will be applied to media.
 // Any timed effect's start and end times should
@@ -20,33 +25,105 @@ type Timeline struct {
 
 // Section defines a bounding box in which
 // the defined effect will appear.
+// X and Y are expressions, see Position.
 type Section struct {
-	X      string  `json:"x,omitempty"`
-	Y      string  `json:"y,omitempty"`
-	Width  float32 `json:"width,omitempty"`
-	Height float32 `json:"height,omitempty"`
+	X      Position `json:"x,omitempty"`
+	Y      Position `json:"y,omitempty"`
+	Width  float32  `json:"width,omitempty"`
+	Height float32  `json:"height,omitempty"`
 }
 
 // Overlay defines a gif, or static image that will be
 // applied to the final media at a position for a
 // specified time period if defined.
+// X and Y are expressions, see Position.
 type Overlay struct {
-	X        string    `json:"x,omitempty"`
-	Y        string    `json:"y,omitempty"`
+	X        Position  `json:"x,omitempty"`
+	Y        Position  `json:"y,omitempty"`
 	Timeline *Timeline `json:"timeline,omitempty"`
 	Source   string    `json:"source,omitempty"`
 
 	// LoopCount if set defines the number of times
 	// that an animated overlay will loop for.
 	LoopCount int `json:"loop_count,omitempty"`
+
+	// Opacity if set ranges from 0 for a fully transparent
+	// overlay to 1 for a fully opaque one. It is left out
+	// when unset so overlays default to being opaque.
+	Opacity float32 `json:"opacity,omitempty"`
 }
 
+// Pad grows the frame to Width and Height, placing the
+// media at X, Y and filling the rest with Color.
+// Values are in pixels unless Unit says otherwise. If Aspect
+// is set, the frame is instead grown to the smallest size of
+// that aspect ratio with the media centered in it.
+// Like those of a Crop, Unit and Aspect are never sent.
 type Pad struct {
 	X      float32 `json:"x,omitempty"`
 	Y      float32 `json:"y,omitempty"`
 	Color  string  `json:"color,omitempty"`
 	Height float32 `json:"height,omitempty"`
 	Width  float32 `json:"width,omitempty"`
+
+	Unit   Unit   `json:"unit,omitempty"`
+	Aspect string `json:"aspect,omitempty"`
+}
+
+// PadAspect pads the media to the aspect ratio width:height
+// e.g PadAspect(1, 1, "black") to letterbox into a square.
+func PadAspect(width, height int, color string) *Pad {
+	return &Pad{Aspect: formatAspect(width, height), Color: color}
+}
+
+// Resolve returns the pad in absolute pixels for
+// media of the given dimensions.
+func (p *Pad) Resolve(width, height int) (*Pad, error) {
+	if p == nil {
+		return nil, ErrNilParamDereference
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrUnknownDimensions, width, height)
+	}
+	w, h := float64(width), float64(height)
+
+	resolved := Pad{Color: p.Color}
+	switch {
+	case p.Aspect != "":
+		ratio, err := parseAspect(p.Aspect)
+		if err != nil {
+			return nil, err
+		}
+		pw, ph := w, h
+		if w/h > ratio {
+			ph = math.Round(w / ratio)
+		} else {
+			pw = math.Round(h * ratio)
+		}
+		resolved.X = float32(math.Floor((pw - w) / 2))
+		resolved.Y = float32(math.Floor((ph - h) / 2))
+		resolved.Width, resolved.Height = float32(pw), float32(ph)
+	case p.Unit == UnitPercent:
+		resolved.X = float32(math.Round(float64(p.X) * w / 100))
+		resolved.Y = float32(math.Round(float64(p.Y) * h / 100))
+		resolved.Width = float32(math.Round(float64(p.Width) * w / 100))
+		resolved.Height = float32(math.Round(float64(p.Height) * h / 100))
+	case p.Unit == "" || p.Unit == UnitPixels:
+		resolved.X, resolved.Y = p.X, p.Y
+		resolved.Width, resolved.Height = p.Width, p.Height
+	default:
+		return nil, fmt.Errorf("unknown unit %q", p.Unit)
+	}
+
+	if resolved.X < 0 || resolved.Y < 0 ||
+		float64(resolved.X)+w > float64(resolved.Width) || float64(resolved.Y)+h > float64(resolved.Height) {
+		return nil, fmt.Errorf("%w: %+v of %dx%d", ErrPadOutOfBounds, resolved, width, height)
+	}
+	return &resolved, nil
+}
+
+func (p *Pad) relative() bool {
+	return p.Aspect != "" || (p.Unit != "" && p.Unit != UnitPixels)
 }
 
 type Flip struct {
@@ -0,0 +1,119 @@
+package gifs
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type HTMLOptions struct {
+	// Width and Height if set are the dimensions of the player.
+	// The player always scales down to fit its container.
+	Width, Height int
+
+	// Class if set is the class attribute of the player.
+	Class string
+
+	// Title is the alternative text of the fallback image.
+	Title string
+}
+
+// HTML renders markup that plays the media like a GIF: a muted,
+// looping <video> with a <source> per video file, using the JPG
+// or GIF as its poster and the GIF for browsers without video.
+// Media without video files is rendered as an <img>.
+func (res Response) HTML(opts HTMLOptions) string {
+	attr := html.EscapeString
+	still := res.still()
+	var sources []MediaType
+	for _, mt := range []MediaType{MP4, WEBM} {
+		if res.File(mt) != "" {
+			sources = append(sources, mt)
+		}
+	}
+	if len(sources) == 0 && still == "" {
+		return ""
+	}
+
+	var attrs string
+	if opts.Width > 0 {
+		attrs += fmt.Sprintf(` width="%d"`, opts.Width)
+	}
+	if opts.Height > 0 {
+		attrs += fmt.Sprintf(` height="%d"`, opts.Height)
+	}
+	if opts.Class != "" {
+		attrs += fmt.Sprintf(` class="%s"`, attr(opts.Class))
+	}
+	attrs += ` style="max-width:100%;height:auto"`
+
+	var img string
+	if still != "" {
+		img = fmt.Sprintf(`<img src="%s" alt="%s"%s>`, attr(still), attr(opts.Title), attrs)
+	}
+	if len(sources) == 0 {
+		return img
+	}
+
+	b := new(strings.Builder)
+	b.WriteString(`<video autoplay loop muted playsinline`)
+	poster := res.File(JPG)
+	if poster == "" {
+		poster = res.File(GIF)
+	}
+	if poster != "" {
+		fmt.Fprintf(b, ` poster="%s"`, attr(poster))
+	}
+	b.WriteString(attrs + ">")
+	for _, mt := range sources {
+		fmt.Fprintf(b, `<source src="%s" type="%s">`, attr(res.File(mt)), mt.ContentType())
+	}
+	b.WriteString(img + "</video>")
+	return b.String()
+}
+
+// still returns the GIF or else the JPG.
+func (res Response) still() string {
+	if gif := res.File(GIF); gif != "" {
+		return gif
+	}
+	return res.File(JPG)
+}
+
+// Markdown renders the GIF, or the JPG if there is none,
+// as an image that links to the media's page.
+func (res Response) Markdown() string {
+	still := res.still()
+	if still == "" {
+		return ""
+	}
+	img := fmt.Sprintf("![gifs.com](%s)", markdownURL(still))
+	if res.Page == "" {
+		return img
+	}
+	return fmt.Sprintf("[%s](%s)", img, markdownURL(res.Page))
+}
+
+// BBCode renders the GIF, or the JPG if there is none,
+// as an image that links to the media's page for forums.
+func (res Response) BBCode() string {
+	still := res.still()
+	if still == "" {
+		return ""
+	}
+	img := "[img]" + bbcodeURL(still) + "[/img]"
+	if res.Page == "" {
+		return img
+	}
+	return "[url=" + bbcodeURL(res.Page) + "]" + img + "[/url]"
+}
+
+// markdownURL escapes the characters that would end a link's destination.
+func markdownURL(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(u)
+}
+
+// bbcodeURL escapes the characters that would end a tag.
+func bbcodeURL(u string) string {
+	return strings.NewReplacer("[", "%5B", "]", "%5D", " ", "%20").Replace(u)
+}
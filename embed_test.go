@@ -0,0 +1,42 @@
+package gifs_test
+
+import (
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestResponseSnippets(t *testing.T) {
+	res := gifs.Response{
+		Page: "https://gifs.com/gif/Z4Wrp2",
+		Files: gifs.FilesMap{
+			"mp4":  "https://j.gifs.com/Z4Wrp2.mp4",
+			"webm": "https://j.gifs.com/Z4Wrp2.webm",
+			"jpg":  "https://j.gifs.com/Z4Wrp2.jpg",
+			"gif":  "https://j.gifs.com/Z4Wrp2.gif",
+		},
+	}
+
+	wantHTML := `<video autoplay loop muted playsinline poster="https://j.gifs.com/Z4Wrp2.jpg" width="480" class="clip" style="max-width:100%;height:auto">` +
+		`<source src="https://j.gifs.com/Z4Wrp2.mp4" type="video/mp4">` +
+		`<source src="https://j.gifs.com/Z4Wrp2.webm" type="video/webm">` +
+		`<img src="https://j.gifs.com/Z4Wrp2.gif" alt="Migos &#34;Dab&#34;" width="480" class="clip" style="max-width:100%;height:auto">` +
+		`</video>`
+	if got := res.HTML(gifs.HTMLOptions{Width: 480, Class: "clip", Title: `Migos "Dab"`}); got != wantHTML {
+		t.Errorf("HTML:\nwant %s\ngot  %s", wantHTML, got)
+	}
+	if want, got := "[![gifs.com](https://j.gifs.com/Z4Wrp2.gif)](https://gifs.com/gif/Z4Wrp2)", res.Markdown(); want != got {
+		t.Errorf("Markdown: want %s, got %s", want, got)
+	}
+	if want, got := "[url=https://gifs.com/gif/Z4Wrp2][img]https://j.gifs.com/Z4Wrp2.gif[/img][/url]", res.BBCode(); want != got {
+		t.Errorf("BBCode: want %s, got %s", want, got)
+	}
+
+	still := gifs.Response{Files: gifs.FilesMap{"jpg": "https://j.gifs.com/Z4Wrp2.jpg"}}
+	if want, got := `<img src="https://j.gifs.com/Z4Wrp2.jpg" alt="" style="max-width:100%;height:auto">`, still.HTML(gifs.HTMLOptions{}); want != got {
+		t.Errorf("HTML: want %s, got %s", want, got)
+	}
+	if got := new(gifs.Response).HTML(gifs.HTMLOptions{}); got != "" {
+		t.Errorf("HTML: want nothing for a response without files, got %s", got)
+	}
+}
@@ -0,0 +1,76 @@
+package gifs
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+var ErrNoFrames = errors.New("expecting atleast one frame")
+
+// defaultFrameDelay is how long frames without a delay are shown for.
+const defaultFrameDelay = 100 * time.Millisecond
+
+// Frame is a still of an animation generated in Go
+// e.g a rendered chart, and how long it is shown for.
+type Frame struct {
+	Image image.Image
+	Delay time.Duration
+}
+
+// EncodeGIF writes frames to w as an animated GIF that loops forever.
+// Frames that aren't paletted are dithered to the Plan 9 palette.
+// Delays are rounded down to the GIF's resolution of 10ms, frames
+// without a delay are shown for 100ms.
+func EncodeGIF(w io.Writer, frames []Frame) error {
+	if len(frames) < 1 {
+		return ErrNoFrames
+	}
+
+	anim := &gif.GIF{}
+	for _, frame := range frames {
+		if frame.Image == nil {
+			return ErrNilParamDereference
+		}
+		bounds := frame.Image.Bounds()
+		paletted, ok := frame.Image.(*image.Paletted)
+		if !ok {
+			paletted = image.NewPaletted(bounds, palette.Plan9)
+			draw.FloydSteinberg.Draw(paletted, bounds, frame.Image, bounds.Min)
+		}
+
+		delay := frame.Delay
+		if delay <= 0 {
+			delay = defaultFrameDelay
+		}
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+
+		if bounds.Max.X > anim.Config.Width {
+			anim.Config.Width = bounds.Max.X
+		}
+		if bounds.Max.Y > anim.Config.Height {
+			anim.Config.Height = bounds.Max.Y
+		}
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// SetFrames encodes frames in memory as an animated GIF, see EncodeGIF,
+// and sets it as the media to upload. The API transcodes it like any
+// other upload so it is also available as MP4.
+func (p *Request) SetFrames(frames []Frame) error {
+	if p == nil {
+		return ErrNilParamDereference
+	}
+	buf := new(bytes.Buffer)
+	if err := EncodeGIF(buf, frames); err != nil {
+		return err
+	}
+	return p.SetMediaWithInfo(bytes.NewReader(buf.Bytes()), "frames.gif", int64(buf.Len()), "image/gif")
+}
@@ -0,0 +1,65 @@
+package gifs_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestUploadFrames(t *testing.T) {
+	fs := newFakeUploadServer()
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	var frames []gifs.Frame
+	for i, c := range []color.Color{color.White, color.Black, color.RGBA{R: 0xff, A: 0xff}} {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+		for x := 0; x < 40; x++ {
+			for y := 0; y < 30; y++ {
+				img.Set(x, y, c)
+			}
+		}
+		frames = append(frames, gifs.Frame{Image: img, Delay: time.Duration(i) * 250 * time.Millisecond})
+	}
+
+	req := &gifs.Request{Title: "Dashboard"}
+	if err := req.SetFrames(frames); err != nil {
+		t.Fatal(err)
+	}
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+	if _, err := c.Upload(context.Background(), req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "image/gif", fs.created["session-1"]["content_type"]; want != got {
+		t.Errorf("content type: want %q, got %q", want, got)
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(fs.sessions["session-1"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 3, len(anim.Image); want != got {
+		t.Fatalf("frames: want %d, got %d", want, got)
+	}
+	for i, want := range []int{10, 25, 50} {
+		if got := anim.Delay[i]; want != got {
+			t.Errorf("#%d: delay want %d, got %d", i, want, got)
+		}
+	}
+	if r, _, _, _ := anim.Image[2].At(5, 5).RGBA(); r>>8 != 0xff {
+		t.Errorf("expected the last frame to be red")
+	}
+}
+
+func TestEncodeGIFNoFrames(t *testing.T) {
+	if err := gifs.EncodeGIF(new(bytes.Buffer), nil); err == nil {
+		t.Errorf("expected an error")
+	}
+}
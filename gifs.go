@@ -2,6 +2,7 @@ package gifs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,18 +10,22 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/odeke-em/semalim"
+	"github.com/gifs/gifs-go/internal/workpool"
 )
 
 var (
 	ErrExpectingAtLeastOneSource = errors.New("expecting atleast one source")
 	ErrNilParamDereference       = errors.New("nil params dereference")
+	ErrSkipped                   = errors.New("skipped, the bulk import stopped early")
 
-	errUnimplemented  = errors.New("unimplemented")
 	errIllogicalState = errors.New("illogical and unexpected state")
 
 	// If set, enables debug logging.
@@ -28,8 +33,11 @@ var (
 )
 
 const (
-	importEndpointURL             = "https://api.gifs.com/media/import"
+	apiBaseURL                    = "https://api.gifs.com"
+	importEndpointPath            = "/media/import"
+	probeEndpointPath             = "/media/probe"
 	defaultConcurrentImportsCount = 10
+	minFailureSample              = 10
 )
 
 var (
@@ -45,22 +53,86 @@ var (
 )
 
 type Client struct {
-	client *http.Client
-	apiKey string
+	client  *http.Client
+	baseURL string
+
+	// creds provides the API key of each request.
+	creds Credentials
+	// signing if set authenticates requests by HMAC signatures
+	// rather than by sending the API key.
+	signing bool
+
+	// watermark if set is overlaid on every request.
+	watermark *Overlay
+
+	// scheduler if set decides which of the queued
+	// imports and uploads of all callers go next.
+	scheduler *Scheduler
+
+	// conns if set holds a token for each request in flight,
+	// queued counts the requests waiting for one.
+	conns  chan struct{}
+	queued int64
+
+	// breakers if set fails requests to unhealthy hosts early.
+	breakers *breakers
+
+	// mu guards the fields below it.
+	mu    sync.Mutex
+	quota Quota
+	// quotas holds the latest quota of each API key.
+	quotas map[string]Quota
 }
 
 type Option interface {
 	apply(*Client)
 }
 
-type withAPIKey string
+type withCredentials struct {
+	creds Credentials
+}
 
-func (k withAPIKey) apply(g *Client) {
-	g.apiKey = string(k)
+func (wc withCredentials) apply(g *Client) {
+	g.creds = wc.creds
 }
 
+// WithAPIKey authenticates every request with key,
+// it is the same as WithCredentials(StaticKey(key)).
 func WithAPIKey(key string) Option {
-	return withAPIKey(key)
+	return withCredentials{StaticKey(key)}
+}
+
+// WithCredentials authenticates requests with the
+// API keys provided by creds, see Credentials.
+func WithCredentials(creds Credentials) Option {
+	return withCredentials{creds}
+}
+
+type withHMACSigning bool
+
+func (b withHMACSigning) apply(g *Client) {
+	g.signing = bool(b)
+}
+
+// WithHMACSigning authenticates requests by signing them with the API key
+// instead of sending the key itself, so that it never leaves the process.
+// See signRequest for the headers that are sent.
+func WithHMACSigning() Option {
+	return withHMACSigning(true)
+}
+
+type withScheduler struct {
+	s *Scheduler
+}
+
+func (ws withScheduler) apply(g *Client) {
+	g.scheduler = ws.s
+}
+
+// WithScheduler shares s between every ImportBulk, and the methods
+// built on it, of the Client so that no caller starves the others.
+func WithScheduler(s *Scheduler) Option {
+	return withScheduler{s}
 }
 
 type withClient struct {
@@ -75,6 +147,18 @@ func WithHTTPClient(hc *http.Client) Option {
 	return withClient{hc}
 }
 
+type withBaseURL string
+
+func (u withBaseURL) apply(g *Client) {
+	g.baseURL = strings.TrimSuffix(string(u), "/")
+}
+
+// WithBaseURL points the Client at another deployment
+// of the API such as a local fake server in tests.
+func WithBaseURL(u string) Option {
+	return withBaseURL(u)
+}
+
 func New(opts ...Option) (*Client, error) {
 	c := &Client{}
 	for _, o := range opts {
@@ -105,7 +189,11 @@ type Request struct {
 	// deals, special requests and many other good things.
 	// If you don't have an API key, you can get one from
 	// https://gifs.com/dashboard/api
-	APIKey string `json:"api_key,omitempty"`
+	//
+	// If set it overrides the Client's credentials for this
+	// request only. Like them it is sent as a header, it never
+	// appears in bodies nor in the output of String.
+	APIKey string `json:"-"`
 
 	// Tags are used for categorization of media
 	Tags []string `json:"tags,omitempty"`
@@ -120,7 +208,11 @@ type Request struct {
 	Trim *Trim `json:"trim,omitempty"`
 
 	// Only set media if you are performing an upload
+	// see SetMedia and Client.Upload.
 	media io.Reader
+	// mediaInfo is set along with media by SetMediaFile
+	// and SetMediaWithInfo.
+	mediaInfo *mediaInfo
 
 	// Attribution makes an association and gives
 	// credit to the creator of the media.
@@ -132,20 +224,39 @@ type Request struct {
 	// Note:
 	// * The value of (x+width) must be less than or equal to the width of the media
 	// * The value of (y+height) must be less than or equal to the height of the media
+	// Use CropPercent, CropInset or CropAspect to crop sources of unknown resolution.
 	Crop *Crop `json:"crop,omitempty"`
 
 	// Effects defines alterations that will be applied to the final media
 	// in an area that may be defined by a section or a timeline.
 	Effects *Effects `json:"effects,omitempty"`
 
+	// Formats if set restricts the output media
+	// to the listed types e.g only MP4 and GIF.
+	Formats []MediaType `json:"formats,omitempty"`
+
+	// SkipWatermark opts this request out of the
+	// Client's default watermark if any is set.
+	SkipWatermark bool `json:"-"`
+
+	// Priority orders this request among the requests of the same
+	// ImportBulk, and among the queued requests of the same tenant
+	// when the Client has a Scheduler, the higher the sooner.
+	// It defaults to 0 and may be negative.
+	Priority int `json:"-"`
+
 	callbackURI string `json:"-"`
 }
 
+// SetMedia sets the media to be uploaded by Client.Upload, which
+// rejects it if it is detected as neither a video nor an image.
+// See SetMediaFile and SetMediaWithInfo to check it up front.
 func (p *Request) SetMedia(r io.Reader) error {
 	if p == nil {
 		return ErrNilParamDereference
 	}
 	p.media = r
+	p.mediaInfo = nil
 	return nil
 }
 
@@ -186,6 +297,10 @@ type Response struct {
 	Files  FilesMap      `json:"files,omitempty"`
 	Page   string        `json:"page,omitempty"`
 	Error  responseError `json:"error,omitempty"`
+
+	// Skipped is set if the request was never sent because its
+	// BulkImportRequest stopped early, Error is then ErrSkipped.
+	Skipped bool `json:"-"`
 }
 
 func (res Response) HasFiles() bool {
@@ -196,10 +311,6 @@ func (res Response) File(mt MediaType) string {
 	return res.Files[mt.Extension()]
 }
 
-func (g *Client) Upload() (*Response, error) {
-	return nil, errUnimplemented
-}
-
 // Import is a method with which you'll specify atleast
 // an http based URL pointing to media that you'd like
 // to import to gifs.com.
@@ -223,6 +334,8 @@ func (g *Client) Import(req *Request) (*Response, error) {
 
 // ImportSources is a convenience method that allows you to just specify
 // multiple media URLs without having to construct each `Request` object.
+// Sources are canonicalized with ParseSource and any timestamp that
+// they were shared with becomes the start of the Trim.
 func (g *Client) ImportSources(sources ...string) ([]*Response, error) {
 	if len(sources) < 1 {
 		return nil, ErrExpectingAtLeastOneSource
@@ -230,7 +343,11 @@ func (g *Client) ImportSources(sources ...string) ([]*Response, error) {
 
 	preparedRequest := []*Request{}
 	for _, source := range sources {
-		preparedRequest = append(preparedRequest, &Request{URL: source})
+		req := &Request{URL: source}
+		if src, err := ParseSource(source); err == nil {
+			req = src.Request()
+		}
+		preparedRequest = append(preparedRequest, req)
 	}
 
 	bip := &BulkImportRequest{Requests: preparedRequest}
@@ -240,6 +357,18 @@ func (g *Client) ImportSources(sources ...string) ([]*Response, error) {
 type BulkImportRequest struct {
 	ConcurrentImports uint
 	Requests          []*Request
+
+	// StopOnFirstError, MaxFailures and MaxFailureRatio stop the
+	// import early, the responses of the requests that were not
+	// sent by then are marked Skipped. Requests in flight finish.
+	//
+	// StopOnFirstError is the same as MaxFailures of 1.
+	StopOnFirstError bool
+	// MaxFailures if set stops the import once that many requests failed.
+	MaxFailures int
+	// MaxFailureRatio if set stops the import once the ratio of failed to
+	// finished requests exceeds it, counting from the 10th finished one.
+	MaxFailureRatio float64
 }
 
 func (p *Request) transformToImportBody() ([]byte, error) {
@@ -247,7 +376,11 @@ func (p *Request) transformToImportBody() ([]byte, error) {
 		return nil, ErrNilParamDereference
 	}
 
-	return json.Marshal(p)
+	sent, err := p.inPixels()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sent)
 }
 
 func copyHeaders(from, to http.Header) {
@@ -259,28 +392,144 @@ func copyHeaders(from, to http.Header) {
 	}
 }
 
-func (g *Client) doPOSTRequest(uri string, req *Request, headers http.Header) (*http.Response, error) {
+func (g *Client) doPOSTRequest(ctx context.Context, uri string, req *Request, headers http.Header) (*http.Response, error) {
 	b, err := req.transformToImportBody()
 	if err != nil {
 		return nil, err
 	}
-	debugLogPrintf("body %s for req: %+v", b, req)
-	httpReq, err := http.NewRequest("POST", uri, bytes.NewReader(b))
+	debugLogPrintf("body %s for req: %v", b, req)
+	return g.do(contextWithRequest(ctx, req), "POST", uri, b, headers)
+}
+
+// do is the single place through which every request to the API
+// is sent, a nil body is sent as is otherwise it is sent as JSON.
+func (g *Client) do(ctx context.Context, method, uri string, body []byte, headers http.Header) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, uri, bodyReader)
 	if err != nil {
 		return nil, err
 	}
 
 	copyHeaders(headers, httpReq.Header)
-	httpReq.Header.Set("Content-Type", "application/json")
-	if g.apiKey != "" {
-		httpReq.Header.Set("Gifs-Api-Key", g.apiKey)
+	if body != nil && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	// Only the API gets to see the key, not hosts that it links to.
+	toAPI := g.isAPIHost(httpReq.URL)
+	var apiKey string
+	if toAPI {
+		if apiKey, err = g.apiKeyFor(ctx); err != nil {
+			return nil, err
+		}
+		switch {
+		case apiKey == "":
+		case g.signing:
+			signRequest(httpReq, body, apiKey, time.Now())
+		default:
+			httpReq.Header.Set("Gifs-Api-Key", apiKey)
+		}
 	}
 
-	return g.httpClient().Do(httpReq)
+	var b *breaker
+	if g.breakers != nil {
+		b = g.breakers.get(httpReq.URL.Host)
+	}
+	done, err := b.allow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	release, err := g.acquireConn(ctx)
+	if err != nil {
+		done(breakerIgnored)
+		return nil, err
+	}
+	res, err := g.httpClient().Do(httpReq)
+	if err != nil {
+		release()
+		// A cancelled call tells nothing of the host, but one
+		// that ran out of time waiting for it does.
+		if ctx.Err() == context.Canceled {
+			done(breakerIgnored)
+		} else {
+			done(breakerFailure)
+		}
+		return nil, err
+	}
+	if res.StatusCode >= 500 {
+		done(breakerFailure)
+	} else {
+		done(breakerSuccess)
+	}
+	if toAPI {
+		g.recordQuota(apiKey, res)
+	}
+	// The connection stays in use until the body is closed.
+	res.Body = &releaseOnClose{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// doJSON sends in as the JSON body of the request if non-nil
+// and decodes the "success" part of the API's reply into out.
+func (g *Client) doJSON(ctx context.Context, method, uri string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	res, err := g.do(ctx, method, uri, body, nil)
+	if err != nil {
+		return err
+	}
+	return decodeAPIResponse(res, out)
+}
+
+// decodeAPIResponse closes res after decoding the "success"
+// part of its body into out, or returning the API's error.
+func decodeAPIResponse(res *http.Response, out interface{}) error {
+	defer res.Body.Close()
+	slurp, err := ioutil.ReadAll(res.Body)
+	debugLogPrintf("status: %s slurp: %s err: %v\n", res.Status, slurp, err)
+	if err != nil {
+		return err
+	}
+
+	wrapperRes := new(struct {
+		Success json.RawMessage `json:"success,omitempty"`
+		Errors  *responseError  `json:"errors,omitempty"`
+	})
+	if len(slurp) > 0 {
+		if err := json.Unmarshal(slurp, wrapperRes); err != nil && res.StatusCode < 300 {
+			return err
+		}
+	}
+	if wrapperRes.Errors != nil {
+		return wrapperRes.Errors
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %q", res.Status)
+	}
+	if out == nil || len(wrapperRes.Success) == 0 {
+		return nil
+	}
+	return json.Unmarshal(wrapperRes.Success, out)
+}
+
+func (g *Client) endpoint(path string) string {
+	if g.baseURL != "" {
+		return g.baseURL + path
+	}
+	return apiBaseURL + path
 }
 
-func (g *Client) doMultipartUpload() (*http.Response, error) {
-	return nil, errUnimplemented
+func (g *Client) isAPIHost(u *url.URL) bool {
+	base, err := url.Parse(g.endpoint(""))
+	return err == nil && strings.EqualFold(base.Host, u.Host)
 }
 
 func (g *Client) httpClient() *http.Client {
@@ -301,126 +550,159 @@ type httpRequestJob struct {
 	uri     string
 	req     *Request
 	headers http.Header
+	typ     jobType
 
-	uuid uint64
-	g    *Client
-	typ  jobType
+	// uploadOptions is only used by upload jobs.
+	uploadOptions *UploadOptions
 }
 
-func (hj httpRequestJob) Id() interface{} {
-	return hj.uuid
-}
+func (g *Client) doJob(ctx context.Context, id int, hj httpRequestJob) (*wrapperResponse, error) {
+	if hj.typ == uploadRequest {
+		res, err := g.Upload(ctx, hj.req, hj.uploadOptions)
+		debugLogPrintf("id: %v upload response: %v err: %v\n", id, res, err)
+		if err != nil {
+			return nil, err
+		}
+		return &wrapperResponse{Success: res}, nil
+	}
 
-func (hj httpRequestJob) Do() (interface{}, error) {
-	res, err := hj.g.doPOSTRequest(hj.uri, hj.req, hj.headers)
-	debugLogPrintf("id: %v httpResposne: %v err: %v\n", hj.uuid, res, err)
+	res, err := g.doPOSTRequest(ctx, hj.uri, hj.req, hj.headers)
+	debugLogPrintf("id: %v httpResposne: %v err: %v\n", id, res, err)
 	if err != nil {
 		return nil, err
 	}
 
 	slurp, err := ioutil.ReadAll(res.Body)
-	debugLogPrintf("id: %v slurp: %s err: %v\n", hj.uuid, slurp, err)
+	_ = res.Body.Close()
+	debugLogPrintf("id: %v slurp: %s err: %v\n", id, slurp, err)
 	if err != nil {
 		return nil, err
 	}
-	_ = res.Body.Close()
 	wrapperRes := new(wrapperResponse)
 	err = json.Unmarshal(slurp, wrapperRes)
-	debugLogPrintf("id: %v after unmarshalling, got %v err: %v\n", hj.uuid, wrapperRes, err)
+	debugLogPrintf("id: %v after unmarshalling, got %v err: %v\n", id, wrapperRes, err)
 	if err != nil {
 		return nil, err
 	}
 	return wrapperRes, nil
 }
 
-func categorizeParallelJobResponses(resultsChan chan semalim.Result, maxResponseId uint64) ([]*Response, error) {
-	idList := []uint64{}
-	idMap := make(map[uint64]*Response)
-
-	for result := range resultsChan {
-		res, err, id := result.Value(), result.Err(), result.Id()
-		debugLogPrintf("id: %d res: %v err: %v", id, res, err)
+// ImportBulk is a convenience method that helps you import multiple media
+// in one pass, however import requests will be made in parallel to
+// the API. Responses per request will be matched by index/order of the requests.
+//
+// Imports run in the background context, so tenants as set by
+// ContextWithTenant don't apply: a request's tenant is its CreatedFrom.
+func (g *Client) ImportBulk(bip *BulkImportRequest) ([]*Response, error) {
+	if bip == nil {
+		return nil, ErrNilParamDereference
+	}
+	concurrentImports := defaultConcurrentImportsCount
+	if bip.ConcurrentImports > 0 {
+		concurrentImports = int(bip.ConcurrentImports)
+	}
 
-		var idKey uint64
-		switch v := id.(type) {
-		case int:
-			idKey = uint64(v)
-		case uint64:
-			idKey = v
-		case int64:
-			idKey = uint64(v)
-		default:
-			parsedI, err := strconv.ParseUint(fmt.Sprintf("%s", v), 10, 64)
-			if err != nil {
-				// TODO: Log this to the user?
-				// Otherwise we don't want to mess up our unique results
-				// Shouldn't happen but if it does alas
-				continue
-			}
-			idKey = parsedI
+	jobs := make([]httpRequestJob, len(bip.Requests))
+	for i, req := range bip.Requests {
+		if req == nil {
+			return nil, ErrNilParamDereference
 		}
-
-		var finalRes *Response
-		if res != nil {
-			wrapRes := res.(*wrapperResponse)
-			if wrapRes != nil {
-				finalRes = wrapRes.Success
-				if err == nil {
-					err = wrapRes.Errors
-				}
-			}
+		jobs[i] = httpRequestJob{uri: g.endpoint(importEndpointPath), req: g.prepareRequest(req), typ: postRequest}
+	}
+	budget := &failureBudget{maxFailures: bip.MaxFailures, maxRatio: bip.MaxFailureRatio}
+	if bip.StopOnFirstError {
+		budget.maxFailures = 1
+	}
+	return g.runJobs(context.Background(), jobs, concurrentImports, budget)
+}
+
+// runJobs runs jobs with at most concurrency of them in parallel, the
+// responses are in the same order as the jobs and there is one per job.
+// A job that fails has the error in its response's Error. Once budget,
+// if non-nil, is exceeded the jobs yet to start are skipped. Jobs are
+// started by Priority, then in order.
+func (g *Client) runJobs(ctx context.Context, jobs []httpRequestJob, concurrency int, budget *failureBudget) ([]*Response, error) {
+	// Cancelling stopCtx keeps jobs from starting, jobs
+	// that already started still run under ctx.
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	// The pool hands out jobs in order, so only the next few ever
+	// wait for the scheduler: they are sorted by Priority first.
+	order := make([]int, len(jobs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return jobs[order[i]].req.Priority > jobs[order[j]].req.Priority
+	})
+	do := func(stopCtx context.Context, _ int, id int) (*wrapperResponse, error) {
+		hj := jobs[id]
+		// Jobs waiting for the scheduler have yet to start.
+		release, err := g.scheduler.acquire(contextWithRequest(stopCtx, hj.req), hj.req.Priority)
+		if err != nil {
+			return nil, err
 		}
+		defer release()
 
-		if finalRes == nil {
-			finalRes = new(Response)
-			if err != nil {
-				finalRes.Error = responseError{Message: err.Error()}
-			}
+		wrapRes, err := g.doJob(ctx, id, hj)
+		if budget.record(err != nil || wrapRes.Errors != nil) {
+			debugLogPrintf("id: %v exceeded the failure budget, stopping", id)
+			stop()
 		}
-		idMap[idKey] = finalRes
-		idList = append(idList, idKey)
+		return wrapRes, err
 	}
-
-	debugLogPrintf("idMap: %v\n", idMap)
-	// Now we've got to sort the results in the order that their requests were initially prepared
-	responsesList := make([]*Response, maxResponseId)
-
-	sort.Sort(uint64Slice(idList))
-	for _, id := range idList {
-		debugLogPrintf("\n\nid: %v v: %v\n\n", id, idMap[id])
-		responsesList[id] = idMap[id]
+	results := make([]workpool.Result[*wrapperResponse], len(jobs))
+	for _, result := range workpool.Run(stopCtx, order, concurrency, do) {
+		results[order[result.Index]] = result
 	}
 
-	return responsesList, nil
+	responses := make([]*Response, len(results))
+	for i, result := range results {
+		res := new(Response)
+		switch wrapRes := result.Value; {
+		case result.Err != nil && result.Err == stopCtx.Err():
+			res.Skipped = true
+			res.Error = responseError{Message: ErrSkipped.Error()}
+		case result.Err != nil:
+			res.Error = responseError{Message: result.Err.Error()}
+		case wrapRes.Errors != nil:
+			if wrapRes.Success != nil {
+				res = wrapRes.Success
+			}
+			res.Error = *wrapRes.Errors
+		case wrapRes.Success != nil:
+			res = wrapRes.Success
+		}
+		responses[i] = res
+	}
+	return responses, nil
 }
 
-type uint64Slice []uint64
+// failureBudget decides when a bulk run has seen too many failures,
+// the zero value and nil never do.
+type failureBudget struct {
+	maxFailures int
+	maxRatio    float64
 
-func (u64s uint64Slice) Len() int           { return len(u64s) }
-func (u64s uint64Slice) Less(i, j int) bool { return u64s[i] < u64s[j] }
-func (u64s uint64Slice) Swap(i, j int) {
-	u64s[i], u64s[j] = u64s[j], u64s[i]
+	mu       sync.Mutex
+	finished int
+	failed   int
 }
 
-// ImportBulk is a convenience method that helps you import multiple media
-// in one pass, however import requests will be made in parallel to
-// the API. Responses per request will be matched by index/order of the requests.
-func (g *Client) ImportBulk(bip *BulkImportRequest) ([]*Response, error) {
-	var concurrentImports uint64 = defaultConcurrentImportsCount
-	if bip.ConcurrentImports > 0 {
-		concurrentImports = uint64(bip.ConcurrentImports)
+// record counts a finished job and reports whether the budget is exceeded.
+func (fb *failureBudget) record(failed bool) (exceeded bool) {
+	if fb == nil {
+		return false
 	}
-
-	maxResponseId := uint64(len(bip.Requests))
-	jobsBench := make(chan semalim.Job)
-	go func() {
-		defer close(jobsBench)
-		for i := uint64(0); i < maxResponseId; i++ {
-			req := bip.Requests[i]
-			jobsBench <- httpRequestJob{uri: importEndpointURL, req: req, uuid: uint64(i), g: g}
-		}
-	}()
-
-	resultsChan := semalim.Run(jobsBench, concurrentImports)
-	return categorizeParallelJobResponses(resultsChan, maxResponseId)
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	fb.finished++
+	if failed {
+		fb.failed++
+	}
+	if fb.maxFailures > 0 && fb.failed >= fb.maxFailures {
+		return true
+	}
+	return fb.maxRatio > 0 && fb.finished >= minFailureSample &&
+		float64(fb.failed)/float64(fb.finished) > fb.maxRatio
 }
@@ -1,8 +1,12 @@
 package gifs_test
 
 import (
+	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 
 	gifs "github.com/gifs/gifs-go"
@@ -130,3 +134,97 @@ func TestOptions(t *testing.T) {
 		t.Errorf("API key: want %q, got %q", want, got)
 	}
 }
+
+func TestImportBulkResponses(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch sent := string(body); {
+		case strings.Contains(sent, "unreachable"):
+			return nil, errors.New("connection refused")
+		case strings.Contains(sent, "rejected"):
+			return jsonResponse(http.StatusBadRequest, `{"errors":{"message":"unsupported source"}}`), nil
+		case strings.Contains(sent, "garbled"):
+			return jsonResponse(http.StatusOK, `{"success":`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"success":{"page":"https://gifs.com/gif/Z4Wrp2"}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	sources := []string{"ok", "unreachable", "rejected", "garbled", "ok"}
+	reqs := make([]*gifs.Request, len(sources))
+	for i, source := range sources {
+		reqs[i] = &gifs.Request{URL: source}
+	}
+	responses, err := c.ImportBulk(&gifs.BulkImportRequest{ConcurrentImports: 2, Requests: reqs})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if want, got := len(sources), len(responses); want != got {
+		t.Fatalf("responses: want %d, got %d", want, got)
+	}
+	for i, res := range responses {
+		if res == nil {
+			t.Fatalf("#%d: nil response", i)
+		}
+		failed := res.Error.Message != ""
+		if want := sources[i] != "ok"; want != failed {
+			t.Errorf("#%d %s: want failed %v, got %+v", i, sources[i], want, res)
+		}
+	}
+	if got := responses[2].Error.Message; !strings.Contains(got, "unsupported source") {
+		t.Errorf("rejected: want the API's error, got %q", got)
+	}
+
+	reqs = append(reqs, nil)
+	if _, err := c.ImportBulk(&gifs.BulkImportRequest{Requests: reqs}); err != gifs.ErrNilParamDereference {
+		t.Errorf("nil request: want %v, got %v", gifs.ErrNilParamDereference, err)
+	}
+}
+
+func TestDefaultWatermark(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req := new(struct {
+			URL     string        `json:"source"`
+			Effects *gifs.Effects `json:"effects"`
+		})
+		if err := json.Unmarshal(body, req); err != nil {
+			t.Errorf("unmarshal body: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		bodies[req.URL] = string(body)
+		if req.URL == "watermarked" {
+			if req.Effects == nil || len(req.Effects.Overlay) != 2 {
+				t.Errorf("want the request's overlay and the watermark, got %s", body)
+			} else if wm := req.Effects.Overlay[1]; wm.X != "main_w-overlay_w-8" || wm.Y != "main_h-overlay_h-8" || wm.Opacity != 0.5 {
+				t.Errorf("unexpected watermark %+v", wm)
+			}
+		}
+		if req.URL == "skipped" && req.Effects != nil {
+			t.Errorf("want no effects, got %s", body)
+		}
+		return nil, errors.New("not implemented")
+	}
+	hc := &http.Client{Transport: transport(roundTrip)}
+	c, _ := gifs.New(gifs.WithHTTPClient(hc), gifs.WithDefaultWatermark("logo.png", gifs.BottomRight, 8, 0.5))
+
+	watermarked := &gifs.Request{
+		URL:     "watermarked",
+		Effects: &gifs.Effects{Overlay: []*gifs.Overlay{{Source: "sticker.png"}}},
+	}
+	_, err := c.ImportBulk(&gifs.BulkImportRequest{
+		Requests: []*gifs.Request{watermarked, {URL: "skipped", SkipWatermark: true}},
+	})
+	if err != nil {
+		t.Errorf("want nil, got err %v", err)
+	}
+	if want, got := 2, len(bodies); want != got {
+		t.Errorf("Requests: want %d, got %d", want, got)
+	}
+	if want, got := 1, len(watermarked.Effects.Overlay); want != got {
+		t.Errorf("caller's request was modified: want %d overlays, got %d", want, got)
+	}
+}
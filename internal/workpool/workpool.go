@@ -0,0 +1,79 @@
+// Package workpool runs jobs on a bounded number of goroutines
+// and collects their results in the order of the jobs.
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Result is the outcome of the job at Index.
+type Result[R any] struct {
+	Index int
+	Value R
+	Err   error
+}
+
+// PanicError is the Err of a job that panicked, the
+// panic is recovered so that the other jobs carry on.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("workpool: job panicked: %v", pe.Value)
+}
+
+// Run calls do for each of jobs with at most concurrency of the calls
+// in flight, less than 1 means 1, and returns once all have returned.
+// There is exactly one result per job, results[i] being that of jobs[i].
+//
+// Once ctx is done the jobs that have yet to start are not started,
+// their results hold ctx.Err() instead.
+func Run[T, R any](ctx context.Context, jobs []T, concurrency int, do func(ctx context.Context, i int, job T) (R, error)) []Result[R] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	results := make([]Result[R], len(jobs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = runOne(ctx, i, jobs[i], do)
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
+
+func runOne[T, R any](ctx context.Context, i int, job T, do func(context.Context, int, T) (R, error)) (res Result[R]) {
+	res.Index = i
+	if err := ctx.Err(); err != nil {
+		res.Err = err
+		return res
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			res.Err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	res.Value, res.Err = do(ctx, i, job)
+	return res
+}
@@ -0,0 +1,88 @@
+package workpool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gifs/gifs-go/internal/workpool"
+)
+
+func TestRunOrdersResults(t *testing.T) {
+	jobs := []int{5, 1, 4, 2, 3, 0}
+	var inFlight, maxInFlight int32
+	results := workpool.Run(context.Background(), jobs, 3, func(_ context.Context, i, job int) (int, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&inFlight, -1)
+		// Finish out of order.
+		time.Sleep(time.Duration(job) * time.Millisecond)
+		return job * 10, nil
+	})
+
+	if len(results) != len(jobs) {
+		t.Fatalf("results: want %d, got %d", len(jobs), len(results))
+	}
+	for i, res := range results {
+		if res.Index != i || res.Value != jobs[i]*10 || res.Err != nil {
+			t.Errorf("#%d: got %+v", i, res)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("concurrency: want at most 3, got %d", maxInFlight)
+	}
+}
+
+func TestRunRecoversPanics(t *testing.T) {
+	results := workpool.Run(context.Background(), []string{"ok", "panic", "fail"}, 2, func(_ context.Context, _ int, job string) (string, error) {
+		switch job {
+		case "panic":
+			panic("boom")
+		case "fail":
+			return "", errors.New("failed")
+		}
+		return job, nil
+	})
+
+	if results[0].Value != "ok" || results[0].Err != nil {
+		t.Errorf("ok: got %+v", results[0])
+	}
+	var pe *workpool.PanicError
+	if !errors.As(results[1].Err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("panic: got %+v", results[1])
+	}
+	if results[2].Err == nil || results[2].Err.Error() != "failed" {
+		t.Errorf("fail: got %+v", results[2])
+	}
+}
+
+func TestRunCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started int32
+	results := workpool.Run(ctx, make([]struct{}, 10), 1, func(ctx context.Context, i int, _ struct{}) (int, error) {
+		atomic.AddInt32(&started, 1)
+		if i == 2 {
+			cancel()
+		}
+		return i, nil
+	})
+
+	if started != 3 {
+		t.Errorf("started: want 3, got %d", started)
+	}
+	for i, res := range results {
+		if i <= 2 && res.Err != nil {
+			t.Errorf("#%d: got %v", i, res.Err)
+		}
+		if i > 2 && res.Err != context.Canceled {
+			t.Errorf("#%d: want %v, got %v", i, context.Canceled, res.Err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package gifs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ListOptions struct {
+	// Tag if set only lists media with this tag.
+	Tag string
+
+	// NSFW if set only lists media whose NSFW flag matches it.
+	NSFW *bool
+
+	// CreatedFrom if set only lists media imported with
+	// this Request.CreatedFrom value.
+	CreatedFrom string
+
+	// Since and Until if set bound the media's creation time.
+	Since, Until time.Time
+
+	// PageSize is the number of media fetched per request,
+	// the API picks a default if it is unset.
+	PageSize int
+}
+
+func (lo ListOptions) values() url.Values {
+	query := make(url.Values)
+	if lo.Tag != "" {
+		query.Set("tag", lo.Tag)
+	}
+	if lo.NSFW != nil {
+		query.Set("nsfw", strconv.FormatBool(*lo.NSFW))
+	}
+	if lo.CreatedFrom != "" {
+		query.Set("caller", lo.CreatedFrom)
+	}
+	if !lo.Since.IsZero() {
+		query.Set("since", lo.Since.UTC().Format(time.RFC3339))
+	}
+	if !lo.Until.IsZero() {
+		query.Set("until", lo.Until.UTC().Format(time.RFC3339))
+	}
+	if lo.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(lo.PageSize))
+	}
+	return query
+}
+
+type mediaPage struct {
+	Media      []*Media `json:"media,omitempty"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// MediaIterator walks through listed media, fetching pages as needed:
+//
+//	it := g.ListMedia(ctx, gifs.ListOptions{Tag: "launch"})
+//	for it.Next() {
+//		media := it.Media()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Stopping early is a matter of no longer calling Next.
+type MediaIterator struct {
+	g     *Client
+	ctx   context.Context
+	query url.Values
+
+	page    []*Media
+	cursor  string
+	last    bool
+	current *Media
+	err     error
+}
+
+// ListMedia lists the media of the account of the Client's API key,
+// most recent first, filtered as per opts.
+func (g *Client) ListMedia(ctx context.Context, opts ListOptions) *MediaIterator {
+	it := &MediaIterator{g: g, query: opts.values()}
+	// Every page is fetched with the same key.
+	it.ctx, it.err = g.requireAPIKey(ctx)
+	return it
+}
+
+// Next advances to the next media, it returns false once all
+// the media has been listed or if an error occured.
+func (it *MediaIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.last {
+			it.current = nil
+			return false
+		}
+		it.fetch()
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *MediaIterator) fetch() {
+	query := make(url.Values, len(it.query)+1)
+	for key, values := range it.query {
+		query[key] = values
+	}
+	if it.cursor != "" {
+		query.Set("cursor", it.cursor)
+	}
+
+	page := new(mediaPage)
+	uri := it.g.endpoint(mediaEndpointPath) + "?" + query.Encode()
+	if it.err = it.g.doJSON(it.ctx, "GET", uri, nil, page); it.err != nil {
+		return
+	}
+	it.page, it.cursor = page.Media, page.NextCursor
+	it.last = page.NextCursor == ""
+}
+
+// Media returns the media that Next advanced to.
+func (it *MediaIterator) Media() *Media {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration if any.
+func (it *MediaIterator) Err() error {
+	return it.err
+}
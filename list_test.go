@@ -0,0 +1,73 @@
+package gifs_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestListMedia(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"success": {"media": [{"id": "a"}, {"id": "b"}], "next_cursor": "c2"}}`,
+		"c2": `{"success": {"media": [], "next_cursor": "c3"}}`,
+		"c3": `{"success": {"media": [{"id": "c"}]}}`,
+	}
+	var requests int
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		requests++
+		query := r.URL.Query()
+		want := fmt.Sprintf("caller=%s nsfw=%s since=%s page_size=%s", "importer", "false", "2017-05-15T00:00:00Z", "2")
+		got := fmt.Sprintf("caller=%s nsfw=%s since=%s page_size=%s", query.Get("caller"), query.Get("nsfw"), query.Get("since"), query.Get("page_size"))
+		if want != got {
+			t.Errorf("query: want %q, got %q", want, got)
+		}
+		return jsonResponse(200, pages[query.Get("cursor")]), nil
+	}
+	c, _ := gifs.New(gifs.WithAPIKey("owner"), gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	nsfw := false
+	opts := gifs.ListOptions{
+		CreatedFrom: "importer",
+		NSFW:        &nsfw,
+		Since:       time.Date(2017, 5, 15, 0, 0, 0, 0, time.UTC),
+		PageSize:    2,
+	}
+	var ids []string
+	it := c.ListMedia(context.Background(), opts)
+	for it.Next() {
+		ids = append(ids, it.Media().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "[a b c]", fmt.Sprint(ids); want != got {
+		t.Errorf("ids: want %s, got %s", want, got)
+	}
+	if want, got := 3, requests; want != got {
+		t.Errorf("requests: want %d, got %d", want, got)
+	}
+
+	// Stopping early doesn't fetch further pages.
+	requests = 0
+	it = c.ListMedia(context.Background(), opts)
+	it.Next()
+	it.Next()
+	if want, got := 1, requests; want != got {
+		t.Errorf("requests: want %d, got %d", want, got)
+	}
+}
+
+func TestListMediaNeedsAPIKey(t *testing.T) {
+	c, _ := gifs.New()
+	it := c.ListMedia(context.Background(), gifs.ListOptions{})
+	if it.Next() {
+		t.Errorf("expected no media")
+	}
+	if it.Err() != gifs.ErrAPIKeyRequired {
+		t.Errorf("want %v, got %v", gifs.ErrAPIKeyRequired, it.Err())
+	}
+}
@@ -0,0 +1,156 @@
+package gifs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotMediaURL    = errors.New("not a gifs.com media URL")
+	ErrAPIKeyRequired = errors.New("an API key is required, see WithAPIKey and WithCredentials")
+)
+
+const mediaEndpointPath = "/media"
+
+// Media is media that has been imported to gifs.com.
+type Media struct {
+	ID string `json:"id,omitempty"`
+
+	Title       string       `json:"title,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	NSFW        bool         `json:"nsfw,omitempty"`
+	Attribution *Attribution `json:"attribution,omitempty"`
+	CreatedFrom string       `json:"caller,omitempty"`
+
+	Page   string   `json:"page,omitempty"`
+	Embed  string   `json:"embed,omitempty"`
+	OEmbed string   `json:"oembed,omitempty"`
+	Files  FilesMap `json:"files,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	Views     int64     `json:"views,omitempty"`
+}
+
+func (m *Media) File(mt MediaType) string {
+	return m.Files[mt.Extension()]
+}
+
+// GetMedia retrieves imported media by its ID, see MediaIDFromURL.
+func (g *Client) GetMedia(ctx context.Context, id string) (*Media, error) {
+	if id == "" {
+		return nil, ErrNilParamDereference
+	}
+	media := new(Media)
+	if err := g.doJSON(ctx, "GET", g.mediaURL(id), nil, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (g *Client) mediaURL(id string) string {
+	return g.endpoint(mediaEndpointPath + "/" + url.PathEscape(id))
+}
+
+var mediaIDRegexp = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// MediaIDFromURL extracts the media's ID from the URL of its page
+// e.g https://gifs.com/gif/Z4Wrp2 or of one of its files
+// e.g https://j.gifs.com/Z4Wrp2.mp4 or https://j.gifs.com/Z4Wrp2@small.gif.
+func MediaIDFromURL(rawurl string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil {
+		return "", ErrNotMediaURL
+	}
+	host := strings.ToLower(u.Hostname())
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	var id string
+	switch {
+	case (host == "gifs.com" || host == "www.gifs.com") && len(segments) == 2 &&
+		(segments[0] == "gif" || segments[0] == "embed"):
+		// Pages may be prefixed by a slug of the title e.g "migos-dab-Z4Wrp2".
+		id = segments[1]
+		if i := strings.LastIndex(id, "-"); i >= 0 {
+			id = id[i+1:]
+		}
+	case host == "j.gifs.com" && len(segments) == 1:
+		id = strings.TrimSuffix(segments[0], path.Ext(segments[0]))
+		id = strings.SplitN(id, "@", 2)[0]
+	}
+
+	if !mediaIDRegexp.MatchString(id) {
+		return "", ErrNotMediaURL
+	}
+	return id, nil
+}
+
+// MediaID returns the ID of the imported media,
+// for use with GetMedia and the like.
+func (res Response) MediaID() (string, error) {
+	if id, err := MediaIDFromURL(res.Page); err == nil {
+		return id, nil
+	}
+	for _, file := range res.Files {
+		if id, err := MediaIDFromURL(file); err == nil {
+			return id, nil
+		}
+	}
+	return "", ErrNotMediaURL
+}
+
+// MediaPatch holds the changes to make to imported media,
+// fields left nil are left as they are.
+type MediaPatch struct {
+	Title *string `json:"title,omitempty"`
+	// Tags replaces all the tags, set it to an empty slice to clear them.
+	Tags        *[]string    `json:"tags,omitempty"`
+	NSFW        *bool        `json:"nsfw,omitempty"`
+	Attribution *Attribution `json:"attribution,omitempty"`
+}
+
+// PatchString returns a pointer to s for use in a MediaPatch.
+func PatchString(s string) *string {
+	return &s
+}
+
+// PatchBool returns a pointer to b for use in a MediaPatch.
+func PatchBool(b bool) *bool {
+	return &b
+}
+
+// UpdateMedia changes the media's details as set in patch and returns the
+// updated media. Only the owner of the media may change it, that is the
+// account of the API key that the Client's credentials provide.
+func (g *Client) UpdateMedia(ctx context.Context, id string, patch *MediaPatch) (*Media, error) {
+	if id == "" || patch == nil {
+		return nil, ErrNilParamDereference
+	}
+	ctx, err := g.requireAPIKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	media := new(Media)
+	if err := g.doJSON(ctx, "PATCH", g.mediaURL(id), patch, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// DeleteMedia removes the media and all its files. Only the owner of the
+// media may delete it, that is the account of the Client's API key.
+// Use ContextWithTenant to act on behalf of a tenant, see TenantKeys.
+func (g *Client) DeleteMedia(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrNilParamDereference
+	}
+	ctx, err := g.requireAPIKey(ctx)
+	if err != nil {
+		return err
+	}
+	return g.doJSON(ctx, "DELETE", g.mediaURL(id), nil, nil)
+}
@@ -0,0 +1,113 @@
+package gifs_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestMediaIDFromURL(t *testing.T) {
+	valid := map[string]string{
+		"https://gifs.com/gif/Z4Wrp2":           "Z4Wrp2",
+		"https://www.gifs.com/gif/migos-Z4Wrp2": "Z4Wrp2",
+		"https://gifs.com/embed/Z4Wrp2":         "Z4Wrp2",
+		"https://j.gifs.com/PNoDGy.gif":         "PNoDGy",
+		"https://j.gifs.com/zmp552@small.gif":   "zmp552",
+	}
+	for in, want := range valid {
+		got, err := gifs.MediaIDFromURL(in)
+		if err != nil || got != want {
+			t.Errorf("%q: want %q, got %q err %v", in, want, got, err)
+		}
+	}
+
+	for _, in := range []string{"", "https://example.com/gif/Z4Wrp2", "https://gifs.com/dashboard/api", "https://j.gifs.com/"} {
+		if got, err := gifs.MediaIDFromURL(in); err == nil {
+			t.Errorf("%q: want an error, got %q", in, got)
+		}
+	}
+
+	res := gifs.Response{Files: gifs.FilesMap{"mp4": "https://j.gifs.com/Z4Wrp2.mp4"}}
+	if id, err := res.MediaID(); err != nil || id != "Z4Wrp2" {
+		t.Errorf("MediaID: want %q, got %q err %v", "Z4Wrp2", id, err)
+	}
+}
+
+func TestGetMedia(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		if r.Method != "GET" || r.URL.Path != "/media/Z4Wrp2" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		return jsonResponse(200, `{"success": {
+			"id": "Z4Wrp2", "title": "Migos Dab", "tags": ["migos", "dab"], "nsfw": false,
+			"attribution": {"site": "gifs-developers", "user": "gifs"},
+			"page": "https://gifs.com/gif/Z4Wrp2", "files": {"mp4": "https://j.gifs.com/Z4Wrp2.mp4"},
+			"created_at": "2017-05-15T10:00:00Z", "views": 1024
+		}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	media, err := c.GetMedia(context.Background(), "Z4Wrp2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Title != "Migos Dab" || len(media.Tags) != 2 || media.Attribution.SiteUsername != "gifs" || media.Views != 1024 {
+		t.Errorf("unexpected media %+v", media)
+	}
+	if want := time.Date(2017, 5, 15, 10, 0, 0, 0, time.UTC); !media.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt: want %v, got %v", want, media.CreatedAt)
+	}
+	if want, got := "https://j.gifs.com/Z4Wrp2.mp4", media.File(gifs.MP4); want != got {
+		t.Errorf("File(MP4): want %q, got %q", want, got)
+	}
+}
+
+func TestUpdateAndDeleteMedia(t *testing.T) {
+	var requests []string
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		if want, got := "owner", r.Header.Get("Gifs-Api-Key"); want != got {
+			t.Errorf("API key: want %q, got %q", want, got)
+		}
+		var body []byte
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		if r.Method == "DELETE" {
+			return jsonResponse(200, `{"success": {}}`), nil
+		}
+		return jsonResponse(200, `{"success": {"id": "Z4Wrp2", "title": "Migos Dab", "nsfw": true}}`), nil
+	}
+	hc := &http.Client{Transport: transport(roundTrip)}
+
+	anonymous, _ := gifs.New(gifs.WithHTTPClient(hc))
+	if _, err := anonymous.UpdateMedia(context.Background(), "Z4Wrp2", &gifs.MediaPatch{}); err != gifs.ErrAPIKeyRequired {
+		t.Errorf("want %v, got %v", gifs.ErrAPIKeyRequired, err)
+	}
+
+	c, _ := gifs.New(gifs.WithAPIKey("owner"), gifs.WithHTTPClient(hc))
+	patch := &gifs.MediaPatch{NSFW: gifs.PatchBool(true), Tags: &[]string{}}
+	media, err := c.UpdateMedia(context.Background(), "Z4Wrp2", patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !media.NSFW {
+		t.Errorf("expected the updated media to be NSFW")
+	}
+	if err := c.DeleteMedia(context.Background(), "Z4Wrp2"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`PATCH /media/Z4Wrp2 {"tags":[],"nsfw":true}`,
+		`DELETE /media/Z4Wrp2 `,
+	}
+	if !reflect.DeepEqual(want, requests) {
+		t.Errorf("requests:\nwant %q\ngot  %q", want, requests)
+	}
+}
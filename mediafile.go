@@ -0,0 +1,218 @@
+package gifs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNotMedia      = errors.New("not a video or image")
+	ErrMediaTooLarge = errors.New("media exceeds the maximum upload size")
+)
+
+// sniffLen is the number of bytes that are read to detect
+// the content type, as many as http.DetectContentType uses.
+const sniffLen = 512
+
+type mediaInfo struct {
+	// path is set if the media is to be read from a file
+	// only once the upload starts.
+	path string
+
+	name        string
+	size        int64
+	contentType string
+}
+
+// SetMediaFile sets the media to be uploaded to the file at path.
+// It fails if the file doesn't look like a video or an image.
+// The file is only opened for reading by Client.Upload.
+func (p *Request) SetMediaFile(path string) error {
+	if p == nil {
+		return ErrNilParamDereference
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s: not a regular file", path)
+	}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+
+	contentType, err := checkMediaType(head[:n], "")
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	p.media = nil
+	p.mediaInfo = &mediaInfo{
+		path:        path,
+		name:        filepath.Base(path),
+		size:        fi.Size(),
+		contentType: contentType,
+	}
+	return nil
+}
+
+// SetMediaWithInfo sets the media to be uploaded along with its file name and
+// size, -1 if unknown. The content type is sniffed from the media's first bytes,
+// contentType is only used if sniffing is inconclusive. It fails if the media
+// doesn't look like a video or an image.
+func (p *Request) SetMediaWithInfo(r io.Reader, name string, size int64, contentType string) error {
+	if p == nil {
+		return ErrNilParamDereference
+	}
+
+	r, head, err := peekHead(r)
+	if err != nil {
+		return err
+	}
+	contentType, err = checkMediaType(head, contentType)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	p.media = r
+	p.mediaInfo = &mediaInfo{name: name, size: size, contentType: contentType}
+	return nil
+}
+
+// openMedia returns the media to upload, closer if non-nil
+// must be invoked once done with it.
+func (p *Request) openMedia() (media io.Reader, closer io.Closer, err error) {
+	if p.media != nil {
+		return p.media, nil, nil
+	}
+	if p.mediaInfo == nil || p.mediaInfo.path == "" {
+		return nil, nil, ErrNoMedia
+	}
+	f, err := os.Open(p.mediaInfo.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f, nil
+}
+
+// peekHead reads the first bytes of r for sniffing,
+// the returned reader still yields all of r.
+func peekHead(r io.Reader) (io.Reader, []byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+	// Put back what was read.
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err != nil {
+			return nil, nil, err
+		}
+		return r, head, nil
+	}
+	return io.MultiReader(bytes.NewReader(head), r), head, nil
+}
+
+// checkUpload applies to media of any origin the checks that
+// SetMediaFile and SetMediaWithInfo make, before the upload starts.
+// Media set by SetMedia is sniffed, it is only rejected if detected
+// as something else than a video or an image since its type can't be
+// declared. Media of unknown size is checked against maxSize by first
+// copying it to a temporary file, which cleanup removes.
+func checkUpload(media io.Reader, info *mediaInfo, maxSize int64) (_ io.Reader, cleanup func(), err error) {
+	cleanup = func() {}
+	if info.contentType == "" {
+		var head []byte
+		if media, head, err = peekHead(media); err != nil {
+			return nil, cleanup, err
+		}
+		switch sniffed := sniffContentType(head); {
+		case isMediaType(sniffed):
+			info.contentType = sniffed
+		case sniffed != "application/octet-stream":
+			return nil, cleanup, fmt.Errorf("%w, detected %q", ErrNotMedia, sniffed)
+		}
+	}
+
+	if maxSize <= 0 || info.size >= 0 {
+		return media, cleanup, nil
+	}
+
+	spool, err := ioutil.TempFile("", "gifs-upload-")
+	if err != nil {
+		return nil, cleanup, err
+	}
+	cleanup = func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	n, err := io.Copy(spool, io.LimitReader(media, maxSize+1))
+	if err == nil && n > maxSize {
+		err = fmt.Errorf("%w: maximum is %d bytes", ErrMediaTooLarge, maxSize)
+	}
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	info.size = n
+	return spool, cleanup, nil
+}
+
+// checkMediaType returns the content type sniffed from head
+// falling back to declared, if either is a video or image.
+func checkMediaType(head []byte, declared string) (string, error) {
+	sniffed := sniffContentType(head)
+	if isMediaType(sniffed) {
+		return sniffed, nil
+	}
+	if sniffed == "application/octet-stream" && isMediaType(declared) {
+		return declared, nil
+	}
+	return "", fmt.Errorf("%w, detected %q", ErrNotMedia, sniffed)
+}
+
+func isMediaType(contentType string) bool {
+	return strings.HasPrefix(contentType, "video/") || strings.HasPrefix(contentType, "image/")
+}
+
+// sniffContentType extends http.DetectContentType
+// with video containers that it doesn't recognize.
+func sniffContentType(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if contentType != "application/octet-stream" {
+		return strings.SplitN(contentType, ";", 2)[0]
+	}
+
+	switch {
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		// ISO base media files, such as QuickTime and 3GP,
+		// start with a box naming the file's brand.
+		if string(head[8:12]) == "qt  " {
+			return "video/quicktime"
+		}
+		return "video/mp4"
+	case bytes.HasPrefix(head, []byte("\x1A\x45\xDF\xA3")):
+		return "video/x-matroska"
+	case bytes.HasPrefix(head, []byte("FLV\x01")):
+		return "video/x-flv"
+	}
+	return contentType
+}
@@ -1,11 +1,19 @@
 package gifs
 
+import (
+	"errors"
+	"strings"
+)
+
+var ErrUnknownMediaType = errors.New("unknown media type")
+
 type MediaType uint
 
 const (
 	MP4 MediaType = 1 << iota
 	JPG
 	GIF
+	WEBM
 )
 
 func (mt MediaType) Extension() string {
@@ -18,9 +26,60 @@ func (mt MediaType) Extension() string {
 		return "jpg"
 	case GIF:
 		return "gif"
+	case WEBM:
+		return "webm"
+	}
+}
+
+// ContentType returns the MIME type of media of this type.
+func (mt MediaType) ContentType() string {
+	switch mt {
+	default:
+		return ""
+	case MP4:
+		return "video/mp4"
+	case JPG:
+		return "image/jpeg"
+	case GIF:
+		return "image/gif"
+	case WEBM:
+		return "video/webm"
 	}
 }
 
 func (mt MediaType) String() string {
 	return mt.Extension()
 }
+
+// MediaTypeFromExtension returns the MediaType for
+// an extension such as "mp4" or ".gif".
+func MediaTypeFromExtension(ext string) (MediaType, error) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	for _, mt := range []MediaType{MP4, JPG, GIF, WEBM} {
+		if mt.Extension() == ext {
+			return mt, nil
+		}
+	}
+	if ext == "jpeg" {
+		return JPG, nil
+	}
+	return 0, ErrUnknownMediaType
+}
+
+// MarshalText serializes a MediaType as its extension.
+func (mt MediaType) MarshalText() ([]byte, error) {
+	ext := mt.Extension()
+	if ext == "" {
+		return nil, ErrUnknownMediaType
+	}
+	return []byte(ext), nil
+}
+
+func (mt *MediaType) UnmarshalText(text []byte) error {
+	parsed, err := MediaTypeFromExtension(string(text))
+	if err != nil {
+		return err
+	}
+	*mt = parsed
+	return nil
+}
@@ -0,0 +1,145 @@
+package gifs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoOEmbed    = errors.New("response has no oEmbed URL")
+	ErrUnsafeEmbed = errors.New("oEmbed has no embeddable media from a trusted host")
+)
+
+// OEmbed is an oEmbed description of imported media, see https://oembed.com.
+type OEmbed struct {
+	XMLName xml.Name `json:"-" xml:"oembed"`
+
+	// Type is one of "photo", "video", "link" or "rich".
+	Type    string `json:"type,omitempty" xml:"type,omitempty"`
+	Version string `json:"version,omitempty" xml:"version,omitempty"`
+	Title   string `json:"title,omitempty" xml:"title,omitempty"`
+
+	AuthorName   string `json:"author_name,omitempty" xml:"author_name,omitempty"`
+	AuthorURL    string `json:"author_url,omitempty" xml:"author_url,omitempty"`
+	ProviderName string `json:"provider_name,omitempty" xml:"provider_name,omitempty"`
+	ProviderURL  string `json:"provider_url,omitempty" xml:"provider_url,omitempty"`
+	CacheAge     int64  `json:"cache_age,omitempty" xml:"cache_age,omitempty"`
+
+	ThumbnailURL    string `json:"thumbnail_url,omitempty" xml:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty" xml:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty" xml:"thumbnail_height,omitempty"`
+
+	// URL is the source of a photo.
+	URL string `json:"url,omitempty" xml:"url,omitempty"`
+	// HTML is the markup that embeds a video or rich media as is.
+	// It is provided by a third party, prefer EmbedHTML.
+	HTML   string `json:"html,omitempty" xml:"html,omitempty"`
+	Width  int    `json:"width,omitempty" xml:"width,omitempty"`
+	Height int    `json:"height,omitempty" xml:"height,omitempty"`
+}
+
+// FetchOEmbed retrieves the oEmbed description from res.OEmbed, asking
+// for media no larger than maxWidth by maxHeight if they are non-zero.
+// Both the JSON and XML formats are understood.
+func (g *Client) FetchOEmbed(ctx context.Context, res *Response, maxWidth, maxHeight int) (*OEmbed, error) {
+	if res == nil {
+		return nil, ErrNilParamDereference
+	}
+	if res.OEmbed == "" {
+		return nil, ErrNoOEmbed
+	}
+	u, err := url.Parse(res.OEmbed)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	if maxWidth > 0 {
+		query.Set("maxwidth", strconv.Itoa(maxWidth))
+	}
+	if maxHeight > 0 {
+		query.Set("maxheight", strconv.Itoa(maxHeight))
+	}
+	u.RawQuery = query.Encode()
+
+	httpRes, err := g.do(ctx, "GET", u.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+	slurp, err := ioutil.ReadAll(httpRes.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpRes.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected response status %q", httpRes.Status)
+	}
+
+	oe := new(OEmbed)
+	contentType := httpRes.Header.Get("Content-Type")
+	if strings.Contains(contentType, "xml") || bytes.HasPrefix(bytes.TrimSpace(slurp), []byte("<")) {
+		err = xml.Unmarshal(slurp, oe)
+	} else {
+		err = json.Unmarshal(slurp, oe)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return oe, nil
+}
+
+var iframeSrcRegexp = regexp.MustCompile(`(?i)<iframe\s[^>]*\bsrc\s*=\s*["']([^"']+)["']`)
+
+// EmbedHTML renders markup for the media that only references
+// gifs.com over HTTPS. Rather than passing the oEmbed's HTML
+// through, a fresh <img> or <iframe> is written so that no
+// scripts or event handlers can come along.
+func (o *OEmbed) EmbedHTML() (string, error) {
+	if o == nil {
+		return "", ErrNilParamDereference
+	}
+
+	var src string
+	switch o.Type {
+	case "photo":
+		src = o.URL
+	case "video", "rich":
+		if m := iframeSrcRegexp.FindStringSubmatch(o.HTML); m != nil {
+			src = html.UnescapeString(m[1])
+		}
+	}
+	if !isTrustedEmbedURL(src) {
+		return "", ErrUnsafeEmbed
+	}
+
+	size := ""
+	if o.Width > 0 {
+		size += fmt.Sprintf(` width="%d"`, o.Width)
+	}
+	if o.Height > 0 {
+		size += fmt.Sprintf(` height="%d"`, o.Height)
+	}
+	if o.Type == "photo" {
+		return fmt.Sprintf(`<img src="%s"%s alt="%s">`, html.EscapeString(src), size, html.EscapeString(o.Title)), nil
+	}
+	return fmt.Sprintf(`<iframe src="%s"%s title="%s" frameborder="0" allowfullscreen></iframe>`,
+		html.EscapeString(src), size, html.EscapeString(o.Title)), nil
+}
+
+func isTrustedEmbedURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "gifs.com" || strings.HasSuffix(host, ".gifs.com")
+}
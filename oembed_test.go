@@ -0,0 +1,74 @@
+package gifs_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestFetchOEmbed(t *testing.T) {
+	replies := map[string]string{
+		"json": `{"type": "video", "version": "1.0", "title": "Dab <3", "provider_name": "gifs.com",
+			"thumbnail_url": "https://j.gifs.com/Z4Wrp2.jpg", "width": 480, "height": 270,
+			"html": "<iframe src=\"https://gifs.com/embed/Z4Wrp2\" onload=\"alert(1)\"></iframe><script>alert(2)</script>"}`,
+		"xml": `<?xml version="1.0" encoding="utf-8"?>
+			<oembed><type>photo</type><version>1.0</version><title>Dab</title>
+			<url>https://j.gifs.com/Z4Wrp2.gif</url><width>240</width><height>135</height></oembed>`,
+	}
+	var apiKey string
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		apiKey = r.Header.Get("Gifs-Api-Key")
+		if want, got := "480", r.URL.Query().Get("maxwidth"); want != got {
+			t.Errorf("maxwidth: want %q, got %q", want, got)
+		}
+		res := jsonResponse(200, replies[r.URL.Query().Get("format")])
+		if r.URL.Query().Get("format") == "xml" {
+			res.Header.Set("Content-Type", "text/xml")
+		}
+		return res, nil
+	}
+	c, _ := gifs.New(gifs.WithAPIKey("secret"), gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	res := &gifs.Response{OEmbed: "https://gifs.com/oembed?url=https%3A%2F%2Fgifs.com%2Fgif%2FZ4Wrp2&format=json"}
+	oe, err := c.FetchOEmbed(context.Background(), res, 480, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiKey != "" {
+		t.Errorf("the API key was sent to a host other than the API's")
+	}
+	if oe.Type != "video" || oe.Width != 480 || oe.Height != 270 || oe.ProviderName != "gifs.com" {
+		t.Errorf("unexpected oEmbed %+v", oe)
+	}
+	embed, err := oe.EmbedHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<iframe src="https://gifs.com/embed/Z4Wrp2" width="480" height="270" title="Dab &lt;3" frameborder="0" allowfullscreen></iframe>`
+	if embed != want {
+		t.Errorf("EmbedHTML:\nwant %s\ngot  %s", want, embed)
+	}
+
+	res.OEmbed = "https://gifs.com/oembed?url=https%3A%2F%2Fgifs.com%2Fgif%2FZ4Wrp2&format=xml"
+	if oe, err = c.FetchOEmbed(context.Background(), res, 480, 0); err != nil {
+		t.Fatal(err)
+	}
+	if oe.Type != "photo" || oe.URL != "https://j.gifs.com/Z4Wrp2.gif" || oe.Width != 240 {
+		t.Errorf("unexpected oEmbed %+v", oe)
+	}
+	if embed, err = oe.EmbedHTML(); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<img src="https://j.gifs.com/Z4Wrp2.gif" width="240" height="135" alt="Dab">`; embed != want {
+		t.Errorf("EmbedHTML:\nwant %s\ngot  %s", want, embed)
+	}
+}
+
+func TestEmbedHTMLUntrusted(t *testing.T) {
+	oe := &gifs.OEmbed{Type: "video", HTML: `<iframe src="https://evil.example.com/x"></iframe>`}
+	if _, err := oe.EmbedHTML(); err == nil {
+		t.Errorf("expected an embed from an untrusted host to be rejected")
+	}
+}
@@ -0,0 +1,298 @@
+package gifs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformedPosition = errors.New("malformed position")
+
+// Position is the X or Y coordinate of an Overlay or a Section.
+// Unlike Crop and Pad which are plain pixel values, positions are
+// expressions that the API evaluates against each frame, so that
+// media can be placed without knowing the source's resolution.
+//
+// An expression is made of numbers, the operators + - * /,
+// parentheses and the variables:
+//
+//	main_w, main_h:       width and height of the video (also W and H)
+//	overlay_w, overlay_h: width and height of the overlay (also w and h)
+//
+// e.g "100", "main_w*25/100" or "main_w-overlay_w-10".
+//
+// In a Preset a position may also reference variables e.g "${x}".
+// Such positions are encoded and decoded as they are, and checked
+// once Preset.Apply has resolved them. Validate rejects them.
+type Position string
+
+// Pixels is an absolute offset of n pixels from the left or top.
+func Pixels(n float64) Position {
+	return Position(formatFloat(n))
+}
+
+// PercentX is an offset from the left of percent of the video's width.
+func PercentX(percent float64) Position {
+	return Position("main_w*" + formatFloat(percent) + "/100")
+}
+
+// PercentY is an offset from the top of percent of the video's height.
+func PercentY(percent float64) Position {
+	return Position("main_h*" + formatFloat(percent) + "/100")
+}
+
+// CenterX centers the overlay horizontally.
+func CenterX() Position {
+	return Position("(main_w-overlay_w)/2")
+}
+
+// CenterY centers the overlay vertically.
+func CenterY() Position {
+	return Position("(main_h-overlay_h)/2")
+}
+
+// FromRight places the overlay's right edge margin pixels
+// from the right edge of the video.
+func FromRight(margin float64) Position {
+	return Position("main_w-overlay_w-" + formatFloat(margin))
+}
+
+// FromBottom places the overlay's bottom edge margin pixels
+// from the bottom edge of the video.
+func FromBottom(margin float64) Position {
+	return Position("main_h-overlay_h-" + formatFloat(margin))
+}
+
+// ParsePosition returns s as a Position if it is a well formed expression.
+func ParsePosition(s string) (Position, error) {
+	p := Position(s)
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// Validate reports whether the position is a well formed
+// expression. The empty position is valid and means unset.
+func (p Position) Validate() error {
+	if p == "" {
+		return nil
+	}
+	_, err := p.eval(func(string) (float64, bool) { return 1, true })
+	return err
+}
+
+// Eval computes the position's value in pixels for a video and an
+// overlay of the given dimensions. The empty position evaluates to 0.
+func (p Position) Eval(mainW, mainH, overlayW, overlayH float64) (float64, error) {
+	if p == "" {
+		return 0, nil
+	}
+	values := map[string]float64{
+		"main_w":    mainW,
+		"main_h":    mainH,
+		"overlay_w": overlayW,
+		"overlay_h": overlayH,
+	}
+	return p.eval(func(name string) (float64, bool) {
+		v, ok := values[name]
+		return v, ok
+	})
+}
+
+func (p Position) MarshalJSON() ([]byte, error) {
+	if err := p.validateUnlessTemplated(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(p))
+}
+
+// UnmarshalJSON accepts both strings and plain numbers.
+func (p *Position) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var n float64
+		if json.Unmarshal(b, &n) != nil {
+			return err
+		}
+		s = formatFloat(n)
+	}
+	parsed := Position(s)
+	if err := parsed.validateUnlessTemplated(); err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// validateUnlessTemplated validates positions
+// that do not reference preset variables.
+func (p Position) validateUnlessTemplated() error {
+	if strings.Contains(string(p), "${") {
+		return nil
+	}
+	return p.Validate()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var positionVariables = map[string]string{
+	"main_w":    "main_w",
+	"main_h":    "main_h",
+	"overlay_w": "overlay_w",
+	"overlay_h": "overlay_h",
+	"W":         "main_w",
+	"H":         "main_h",
+	"w":         "overlay_w",
+	"h":         "overlay_h",
+}
+
+// eval computes the value of the expression with lookup
+// resolving each variable by its canonical name.
+func (p Position) eval(lookup func(string) (float64, bool)) (float64, error) {
+	ps := &positionParser{src: string(p), lookup: lookup}
+	v, err := ps.expr()
+	if err == nil && ps.peek() != 0 {
+		err = ps.errorf("unexpected %q", ps.peek())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// positionParser is a recursive descent parser for the grammar:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | variable | "(" expr ")"
+type positionParser struct {
+	src    string
+	pos    int
+	lookup func(string) (float64, bool)
+}
+
+func (ps *positionParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%v %q: at offset %d: %s", ErrMalformedPosition, ps.src, ps.pos, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next non-space byte or 0 at the end of input.
+func (ps *positionParser) peek() byte {
+	for ps.pos < len(ps.src) && ps.src[ps.pos] == ' ' {
+		ps.pos++
+	}
+	if ps.pos >= len(ps.src) {
+		return 0
+	}
+	return ps.src[ps.pos]
+}
+
+func (ps *positionParser) expr() (float64, error) {
+	v, err := ps.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch op := ps.peek(); op {
+		case '+', '-':
+			ps.pos++
+			rhs, err := ps.term()
+			if err != nil {
+				return 0, err
+			}
+			if op == '+' {
+				v += rhs
+			} else {
+				v -= rhs
+			}
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (ps *positionParser) term() (float64, error) {
+	v, err := ps.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch op := ps.peek(); op {
+		case '*', '/':
+			ps.pos++
+			rhs, err := ps.unary()
+			if err != nil {
+				return 0, err
+			}
+			if op == '*' {
+				v *= rhs
+			} else {
+				v /= rhs
+			}
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (ps *positionParser) unary() (float64, error) {
+	if ps.peek() == '-' {
+		ps.pos++
+		v, err := ps.unary()
+		return -v, err
+	}
+	return ps.primary()
+}
+
+func (ps *positionParser) primary() (float64, error) {
+	c := ps.peek()
+	switch {
+	case c == 0:
+		return 0, ps.errorf("unexpected end of expression")
+	case c == '(':
+		ps.pos++
+		v, err := ps.expr()
+		if err != nil {
+			return 0, err
+		}
+		if ps.peek() != ')' {
+			return 0, ps.errorf("expecting )")
+		}
+		ps.pos++
+		return v, nil
+	case c == '.' || ('0' <= c && c <= '9'):
+		start := ps.pos
+		for ps.pos < len(ps.src) && (ps.src[ps.pos] == '.' || ('0' <= ps.src[ps.pos] && ps.src[ps.pos] <= '9')) {
+			ps.pos++
+		}
+		v, err := strconv.ParseFloat(ps.src[start:ps.pos], 64)
+		if err != nil {
+			ps.pos = start
+			return 0, ps.errorf("bad number")
+		}
+		return v, nil
+	case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+		start := ps.pos
+		for ps.pos < len(ps.src) && strings.IndexByte("_abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", ps.src[ps.pos]) >= 0 {
+			ps.pos++
+		}
+		name, ok := positionVariables[ps.src[start:ps.pos]]
+		if !ok {
+			ps.pos = start
+			return 0, ps.errorf("unknown variable")
+		}
+		v, ok := ps.lookup(name)
+		if !ok {
+			ps.pos = start
+			return 0, ps.errorf("%s is undefined here", name)
+		}
+		return v, nil
+	default:
+		return 0, ps.errorf("unexpected %q", c)
+	}
+}
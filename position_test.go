@@ -0,0 +1,85 @@
+package gifs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestParsePosition(t *testing.T) {
+	valid := []string{
+		"",
+		"100",
+		"10.5",
+		"main_w*25/100",
+		"main_w-overlay_w-10",
+		"(main_h - overlay_h) / 2",
+		"W-w-10",
+		"-5",
+	}
+	for _, s := range valid {
+		if _, err := gifs.ParsePosition(s); err != nil {
+			t.Errorf("%q: want nil, got err %v", s, err)
+		}
+	}
+
+	malformed := []string{
+		"10px",
+		"main_width-10",
+		"(main_w-overlay_w/2",
+		"main_w-",
+		"1..2",
+		"50%",
+	}
+	for _, s := range malformed {
+		if _, err := gifs.ParsePosition(s); err == nil {
+			t.Errorf("%q: want an error", s)
+		}
+	}
+}
+
+func TestPositionConstructors(t *testing.T) {
+	tests := []struct {
+		pos  gifs.Position
+		want gifs.Position
+	}{
+		{gifs.Pixels(100), "100"},
+		{gifs.PercentX(12.5), "main_w*12.5/100"},
+		{gifs.PercentY(50), "main_h*50/100"},
+		{gifs.CenterX(), "(main_w-overlay_w)/2"},
+		{gifs.FromRight(10), "main_w-overlay_w-10"},
+		{gifs.FromBottom(0), "main_h-overlay_h-0"},
+	}
+	for i, tt := range tests {
+		if tt.pos != tt.want {
+			t.Errorf("#%d: want %q, got %q", i, tt.want, tt.pos)
+		}
+		if err := tt.pos.Validate(); err != nil {
+			t.Errorf("#%d: want nil, got err %v", i, err)
+		}
+	}
+}
+
+func TestPositionJSON(t *testing.T) {
+	overlay := &gifs.Overlay{X: gifs.FromRight(10), Y: "10"}
+	b, err := json.Marshal(overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := `{"x":"main_w-overlay_w-10","y":"10"}`, string(b); want != got {
+		t.Errorf("want %s, got %s", want, got)
+	}
+
+	if _, err := json.Marshal(&gifs.Overlay{X: "10px"}); err == nil {
+		t.Errorf("expected marshaling a malformed position to fail")
+	}
+
+	decoded := new(gifs.Overlay)
+	if err := json.Unmarshal([]byte(`{"x": 25, "y": "main_h/2"}`), decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.X != "25" || decoded.Y != "main_h/2" {
+		t.Errorf("unexpected positions %q, %q", decoded.X, decoded.Y)
+	}
+}
@@ -0,0 +1,323 @@
+package gifs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownPresetFormat = errors.New("unknown preset format, expecting .json or a registered format")
+	ErrUndefinedVariable   = errors.New("undefined preset variable")
+)
+
+// Preset bundles effects, crop, output formats and tag
+// defaults into a reusable template that can be kept
+// in JSON files, or YAML files see package presetyaml,
+// and applied to many requests.
+//
+// Any string value in a preset, positions included, may
+// reference a named variable as ${name}, for example the
+// source of a watermark overlay. Variables are resolved on
+// Apply. A literal dollar sign may be written as $ or $$.
+type Preset struct {
+	Name string `json:"name,omitempty"`
+
+	// Variables holds the default values of variables
+	// referenced by the preset. Values passed to Apply
+	// take precedence over these.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	Title   string      `json:"title,omitempty"`
+	Tags    []string    `json:"tags,omitempty"`
+	Trim    *Trim       `json:"trim,omitempty"`
+	Crop    *Crop       `json:"crop,omitempty"`
+	Effects *Effects    `json:"effects,omitempty"`
+	Formats []MediaType `json:"formats,omitempty"`
+}
+
+// presetTemplate is the portion of a Preset that is
+// subject to variable expansion and gets applied to
+// a Request.
+type presetTemplate struct {
+	Title   string
+	Tags    []string
+	Trim    *Trim
+	Crop    *Crop
+	Effects *Effects
+	Formats []MediaType
+}
+
+var (
+	presetFormatsMu sync.RWMutex
+	presetFormats   = map[string]func([]byte) (*Preset, error){
+		".json": ParsePresetJSON,
+	}
+)
+
+// RegisterPresetFormat makes LoadPreset decode files with the extension
+// ext, e.g ".yaml", using parse. It is meant to be called from the init
+// of the package providing the format, like presetyaml does.
+func RegisterPresetFormat(ext string, parse func([]byte) (*Preset, error)) {
+	presetFormatsMu.Lock()
+	defer presetFormatsMu.Unlock()
+	presetFormats[strings.ToLower(ext)] = parse
+}
+
+// LoadPreset reads a preset from a file, picking the decoder by the
+// file's extension among .json and the registered formats.
+func LoadPreset(path string) (*Preset, error) {
+	presetFormatsMu.RLock()
+	parse := presetFormats[strings.ToLower(filepath.Ext(path))]
+	presetFormatsMu.RUnlock()
+	if parse == nil {
+		return nil, ErrUnknownPresetFormat
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
+
+func ParsePresetJSON(data []byte) (*Preset, error) {
+	p := new(Preset)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+
+	// Positions may only be well formed once variables are
+	// expanded, check them early with placeholder variables.
+	placeholder := func(string) (string, bool) { return "0", true }
+	if _, err := p.expand(placeholder); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Apply merges the preset into req after resolving its variables.
+// Values already set on the request win over the preset's:
+// Title, Trim, Crop and Formats are only filled in if unset,
+// tags are added if missing and effects are appended.
+func (p *Preset) Apply(req *Request, vars map[string]string) error {
+	if p == nil || req == nil {
+		return ErrNilParamDereference
+	}
+
+	tmpl, err := p.expand(func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		value, ok := p.Variables[name]
+		return value, ok
+	})
+	if err != nil {
+		return err
+	}
+
+	if req.Title == "" {
+		req.Title = tmpl.Title
+	}
+	if req.Trim == nil {
+		req.Trim = tmpl.Trim
+	}
+	if req.Crop == nil {
+		req.Crop = tmpl.Crop
+	}
+	if len(req.Formats) == 0 {
+		req.Formats = tmpl.Formats
+	}
+	req.Tags = mergeTags(req.Tags, tmpl.Tags)
+	req.Effects = mergeEffects(req.Effects, tmpl.Effects)
+	return nil
+}
+
+// expand returns a fresh copy of the preset's template with all
+// variables resolved, so that applying a preset to many requests
+// never shares state between them.
+func (p *Preset) expand(lookup func(string) (string, bool)) (*presetTemplate, error) {
+	src := &presetTemplate{
+		Title:   p.Title,
+		Tags:    p.Tags,
+		Trim:    p.Trim,
+		Crop:    p.Crop,
+		Effects: p.Effects,
+		Formats: p.Formats,
+	}
+	expanded, err := expandVariables(reflect.ValueOf(src), lookup)
+	if err != nil {
+		return nil, fmt.Errorf("preset %q: %v", p.Name, err)
+	}
+	tmpl := expanded.Interface().(*presetTemplate)
+	if err := validatePositions(tmpl.Effects); err != nil {
+		return nil, fmt.Errorf("preset %q: %v", p.Name, err)
+	}
+	return tmpl, nil
+}
+
+// validatePositions checks the positions that were left
+// unvalidated while they referenced variables.
+func validatePositions(effects *Effects) error {
+	if effects == nil {
+		return nil
+	}
+	var positions []Position
+	for _, overlay := range effects.Overlay {
+		if overlay != nil {
+			positions = append(positions, overlay.X, overlay.Y)
+		}
+	}
+	for _, invert := range effects.Invert {
+		if invert != nil && invert.Section != nil {
+			positions = append(positions, invert.Section.X, invert.Section.Y)
+		}
+	}
+	for _, pos := range positions {
+		if err := pos.Validate(); err != nil {
+			return fmt.Errorf("position %q: %v", pos, err)
+		}
+	}
+	return nil
+}
+
+// expandVariables returns a deep copy of v with the
+// variables in all of its strings expanded.
+func expandVariables(v reflect.Value, lookup func(string) (string, bool)) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandString(v.String(), lookup)
+		if err != nil {
+			return v, err
+		}
+		return reflect.ValueOf(expanded).Convert(v.Type()), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := expandVariables(v.Elem(), lookup)
+		if err != nil {
+			return v, err
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(elem)
+		return copied, nil
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue // unexported
+			}
+			field, err := expandVariables(v.Field(i), lookup)
+			if err != nil {
+				return v, err
+			}
+			copied.Field(i).Set(field)
+		}
+		return copied, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := expandVariables(v.Index(i), lookup)
+			if err != nil {
+				return v, err
+			}
+			copied.Index(i).Set(elem)
+		}
+		return copied, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := expandVariables(iter.Value(), lookup)
+			if err != nil {
+				return v, err
+			}
+			copied.SetMapIndex(iter.Key(), value)
+		}
+		return copied, nil
+	default:
+		return v, nil
+	}
+}
+
+// expandString replaces each ${name} in s by its value and each $$ by $,
+// other dollar signs are left as they are e.g "Save $5" is kept whole.
+func expandString(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			buf.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				buf.WriteByte(s[i])
+				continue
+			}
+			name := s[i+2 : i+2+end]
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("%v %q", ErrUndefinedVariable, name)
+			}
+			buf.WriteString(value)
+			i += 2 + end
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func mergeTags(have, defaults []string) []string {
+	seen := make(map[string]bool, len(have))
+	for _, tag := range have {
+		seen[tag] = true
+	}
+	for _, tag := range defaults {
+		if !seen[tag] {
+			seen[tag] = true
+			have = append(have, tag)
+		}
+	}
+	return have
+}
+
+func mergeEffects(have, extra *Effects) *Effects {
+	if extra == nil {
+		return have
+	}
+	if have == nil {
+		return extra
+	}
+	merged := *have
+	merged.Overlay = append(append([]*Overlay(nil), have.Overlay...), extra.Overlay...)
+	merged.Pad = append(append([]*Pad(nil), have.Pad...), extra.Pad...)
+	merged.Flip = append(append([]*Flip(nil), have.Flip...), extra.Flip...)
+	merged.Invert = append(append([]*Invert(nil), have.Invert...), extra.Invert...)
+	return &merged
+}
@@ -0,0 +1,104 @@
+package gifs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestPresetUndefinedVariable(t *testing.T) {
+	preset, err := gifs.ParsePresetJSON([]byte(`{"title": "${caption}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := preset.Apply(new(gifs.Request), nil); err == nil {
+		t.Errorf("expected an error for an undefined variable")
+	}
+}
+
+func TestPresetVariablePositions(t *testing.T) {
+	preset, err := gifs.ParsePresetJSON([]byte(`{
+		"name": "corner",
+		"effects": {"overlay": [{"x": "${x}", "y": "10", "source": "logo.png"}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := new(gifs.Request)
+	if err := preset.Apply(req, map[string]string{"x": "main_w-overlay_w-10"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := gifs.FromRight(10), req.Effects.Overlay[0].X; want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if err := preset.Apply(new(gifs.Request), map[string]string{"x": "10px"}); err == nil {
+		t.Errorf("expected a malformed position to be rejected")
+	}
+}
+
+func TestPresetLiteralDollars(t *testing.T) {
+	preset, err := gifs.ParsePresetJSON([]byte(`{"title": "Save $5 now, $$${price} off"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := new(gifs.Request)
+	if err := preset.Apply(req, map[string]string{"price": "10"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "Save $5 now, $10 off", req.Title; want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestPresetUnknownFormat(t *testing.T) {
+	if _, err := gifs.LoadPreset("testdata/preset.toml"); err != gifs.ErrUnknownPresetFormat {
+		t.Errorf("want %v, got %v", gifs.ErrUnknownPresetFormat, err)
+	}
+}
+
+func TestPresetTypedFields(t *testing.T) {
+	preset := &gifs.Preset{
+		Name:      "corner",
+		Variables: map[string]string{"margin": "10"},
+		Title:     "${title}",
+		Effects: &gifs.Effects{Overlay: []*gifs.Overlay{
+			{X: "main_w-overlay_w-${margin}", Y: gifs.Pixels(10), Source: "logo.png"},
+		}},
+	}
+
+	// Presets built in Go round trip through JSON.
+	data, err := json.Marshal(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := gifs.ParsePresetJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := gifs.Position("main_w-overlay_w-${margin}"), parsed.Effects.Overlay[0].X; want != got {
+		t.Errorf("X: want %q, got %q", want, got)
+	}
+
+	req := new(gifs.Request)
+	if err := parsed.Apply(req, map[string]string{"title": "Launch"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := gifs.FromRight(10), req.Effects.Overlay[0].X; want != got {
+		t.Errorf("X: want %q, got %q", want, got)
+	}
+	if want, got := "Launch", req.Title; want != got {
+		t.Errorf("Title: want %q, got %q", want, got)
+	}
+	if want, got := gifs.Position("main_w-overlay_w-${margin}"), parsed.Effects.Overlay[0].X; want != got {
+		t.Errorf("Apply modified the preset: want %q, got %q", want, got)
+	}
+}
+
+func TestPresetUnknownKey(t *testing.T) {
+	if _, err := gifs.ParsePresetJSON([]byte(`{"efects": {}}`)); err == nil {
+		t.Errorf("expected a misspelt key to be rejected")
+	}
+}
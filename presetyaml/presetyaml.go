@@ -0,0 +1,80 @@
+// Package presetyaml decodes gifs presets written in YAML. It is kept
+// apart from package gifs so that only its importers depend on a YAML
+// library. Importing it, even for side effects only, lets LoadPreset
+// read .yaml and .yml files:
+//
+//	import _ "github.com/gifs/gifs-go/presetyaml"
+package presetyaml
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func init() {
+	gifs.RegisterPresetFormat(".yaml", Parse)
+	gifs.RegisterPresetFormat(".yml", Parse)
+}
+
+// Parse decodes a YAML preset. Keys are the same
+// as those of the JSON form e.g "loop_count".
+func Parse(data []byte) (*gifs.Preset, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	// Round trip through JSON so that the json struct
+	// tags remain the single definition of field names.
+	normalized, err := normalize(raw)
+	if err != nil {
+		return nil, err
+	}
+	asJSON, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return gifs.ParsePresetJSON(asJSON)
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			nv, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = nv
+		}
+		return t, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			ks, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("preset: non-string key %v", key)
+			}
+			nv, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			m[ks] = nv
+		}
+		return m, nil
+	case []interface{}:
+		for i, value := range t {
+			nv, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = nv
+		}
+		return t, nil
+	default:
+		return v, nil
+	}
+}
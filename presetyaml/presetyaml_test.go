@@ -0,0 +1,55 @@
+package presetyaml_test
+
+import (
+	"reflect"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+	_ "github.com/gifs/gifs-go/presetyaml"
+)
+
+func TestLoadPresetYAML(t *testing.T) {
+	preset, err := gifs.LoadPreset("testdata/watermark-square.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &gifs.Request{Tags: []string{"branded", "launch"}}
+	vars := map[string]string{"title": "Launch day"}
+	if err := preset.Apply(req, vars); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "Launch day", req.Title; want != got {
+		t.Errorf("Title: want %q, got %q", want, got)
+	}
+	if want, got := []string{"branded", "launch"}, req.Tags; !reflect.DeepEqual(want, got) {
+		t.Errorf("Tags: want %v, got %v", want, got)
+	}
+	if want, got := []gifs.MediaType{gifs.MP4, gifs.GIF}, req.Formats; !reflect.DeepEqual(want, got) {
+		t.Errorf("Formats: want %v, got %v", want, got)
+	}
+	if req.Effects == nil || len(req.Effects.Overlay) != 1 || len(req.Effects.Pad) != 1 {
+		t.Fatalf("expected one overlay and one pad, got %+v", req.Effects)
+	}
+	overlay := req.Effects.Overlay[0]
+	if want, got := "https://cdn.gifs.com/watermark.png", overlay.Source; want != got {
+		t.Errorf("Overlay.Source: want %q, got %q", want, got)
+	}
+	if want, got := 2, overlay.LoopCount; want != got {
+		t.Errorf("Overlay.LoopCount: want %d, got %d", want, got)
+	}
+
+	// Applying again must not share effects between requests.
+	other := new(gifs.Request)
+	vars["watermark"] = "https://cdn.gifs.com/other.png"
+	if err := preset.Apply(other, vars); err != nil {
+		t.Fatal(err)
+	}
+	if other.Effects.Overlay[0] == overlay {
+		t.Errorf("expected a fresh overlay per request")
+	}
+	if want, got := "https://cdn.gifs.com/watermark.png", overlay.Source; want != got {
+		t.Errorf("first request's overlay was modified: want %q, got %q", want, got)
+	}
+}
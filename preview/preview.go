@@ -0,0 +1,260 @@
+// Package preview renders locally what the gifs.com API will do to a
+// frame of media, so that crops, pads and the placement of overlays and
+// sections can be checked before importing.
+//
+// It works on a single decoded frame in pure Go and only approximates
+// the API's output e.g overlays are drawn as still images.
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+var (
+	ErrNoOverlayImage = errors.New("no image for the overlay, expecting Options.Overlays to be set")
+	ErrMalformedColor = errors.New("malformed color, expecting a name or the form #rrggbb")
+	ErrNilFrame       = errors.New("nil frame")
+)
+
+type Options struct {
+	// Time is the instant in seconds, relative to the start of the
+	// trimmed media, that the frame is at. Effects whose Timeline
+	// doesn't cover Time are left out.
+	Time float32
+
+	// Overlays returns the image of an overlay's source.
+	// It is required if there are any overlays.
+	Overlays func(source string) (image.Image, error)
+}
+
+// Render applies the request's Crop and then its Effects to frame.
+func Render(frame image.Image, req *gifs.Request, opts *Options) (*image.RGBA, error) {
+	if frame == nil {
+		return nil, ErrNilFrame
+	}
+	if req == nil {
+		return nil, gifs.ErrNilParamDereference
+	}
+
+	img := toRGBA(frame)
+	if req.Crop != nil {
+		crop, err := req.Crop.Resolve(img.Bounds().Dx(), img.Bounds().Dy())
+		if err != nil {
+			return nil, err
+		}
+		min := img.Bounds().Min
+		rect := image.Rect(int(crop.X), int(crop.Y), int(crop.X+crop.Width), int(crop.Y+crop.Height)).Add(min)
+		img = toRGBA(img.SubImage(rect))
+	}
+	return Apply(img, req.Effects, opts)
+}
+
+// Apply applies effects to frame in the order pads,
+// flips, inverts and lastly overlays. Nil effects are skipped.
+func Apply(frame image.Image, effects *gifs.Effects, opts *Options) (*image.RGBA, error) {
+	if frame == nil {
+		return nil, ErrNilFrame
+	}
+	if opts == nil {
+		opts = new(Options)
+	}
+	img := toRGBA(frame)
+	if effects == nil {
+		return img, nil
+	}
+
+	for _, pad := range effects.Pad {
+		if pad == nil {
+			continue
+		}
+		var err error
+		if img, err = applyPad(img, pad); err != nil {
+			return nil, err
+		}
+	}
+	for _, flip := range effects.Flip {
+		if flip != nil {
+			img = applyFlip(img, flip)
+		}
+	}
+	for i, invert := range effects.Invert {
+		if invert == nil || !active(invert.Timeline, opts.Time) {
+			continue
+		}
+		if err := applyInvert(img, invert.Section); err != nil {
+			return nil, fmt.Errorf("invert #%d: %v", i, err)
+		}
+	}
+	for i, overlay := range effects.Overlay {
+		if overlay == nil || !active(overlay.Timeline, opts.Time) {
+			continue
+		}
+		if err := applyOverlay(img, overlay, opts); err != nil {
+			return nil, fmt.Errorf("overlay #%d: %v", i, err)
+		}
+	}
+	return img, nil
+}
+
+// toRGBA returns a copy of img with its origin at (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+func active(tl *gifs.Timeline, t float32) bool {
+	if tl == nil {
+		return true
+	}
+	return t >= tl.Start && (tl.End == 0 || t <= tl.End)
+}
+
+func applyPad(img *image.RGBA, pad *gifs.Pad) (*image.RGBA, error) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	resolved, err := pad.Resolve(w, h)
+	if err != nil {
+		return nil, err
+	}
+	bg, err := parseColor(resolved.Color)
+	if err != nil {
+		return nil, err
+	}
+
+	pw, ph := int(resolved.Width), int(resolved.Height)
+	if pw < w {
+		pw = w
+	}
+	if ph < h {
+		ph = h
+	}
+	padded := image.NewRGBA(image.Rect(0, 0, pw, ph))
+	draw.Draw(padded, padded.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	at := image.Pt(int(resolved.X), int(resolved.Y))
+	draw.Draw(padded, img.Bounds().Add(at), img, image.Point{}, draw.Src)
+	return padded, nil
+}
+
+func applyFlip(img *image.RGBA, flip *gifs.Flip) *image.RGBA {
+	if !flip.Horizontal && !flip.Vertical {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	flipped := image.NewRGBA(img.Bounds())
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := x, y
+			if flip.Horizontal {
+				sx = w - 1 - x
+			}
+			if flip.Vertical {
+				sy = h - 1 - y
+			}
+			flipped.SetRGBA(x, y, img.RGBAAt(sx, sy))
+		}
+	}
+	return flipped
+}
+
+func applyInvert(img *image.RGBA, section *gifs.Section) error {
+	rect := img.Bounds()
+	if section != nil {
+		w, h := float64(rect.Dx()), float64(rect.Dy())
+		sw, sh := float64(section.Width), float64(section.Height)
+		if sw == 0 {
+			sw = w
+		}
+		if sh == 0 {
+			sh = h
+		}
+		// Within a section overlay_w and overlay_h are its own dimensions.
+		x, err := section.X.Eval(w, h, sw, sh)
+		if err != nil {
+			return err
+		}
+		y, err := section.Y.Eval(w, h, sw, sh)
+		if err != nil {
+			return err
+		}
+		rect = image.Rect(int(x), int(y), int(x+sw), int(y+sh)).Intersect(rect)
+	}
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			c.R, c.G, c.B = c.A-c.R, c.A-c.G, c.A-c.B
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return nil
+}
+
+func applyOverlay(img *image.RGBA, overlay *gifs.Overlay, opts *Options) error {
+	if opts.Overlays == nil {
+		return ErrNoOverlayImage
+	}
+	src, err := opts.Overlays(overlay.Source)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return ErrNoOverlayImage
+	}
+
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	ob := src.Bounds()
+	ow, oh := float64(ob.Dx()), float64(ob.Dy())
+	x, err := overlay.X.Eval(w, h, ow, oh)
+	if err != nil {
+		return err
+	}
+	y, err := overlay.Y.Eval(w, h, ow, oh)
+	if err != nil {
+		return err
+	}
+
+	var mask image.Image
+	if overlay.Opacity > 0 && overlay.Opacity < 1 {
+		mask = image.NewUniform(color.Alpha{A: uint8(overlay.Opacity * 0xff)})
+	}
+	dst := image.Rect(int(x), int(y), int(x)+ob.Dx(), int(y)+ob.Dy())
+	draw.DrawMask(img, dst, src, ob.Min, mask, image.Point{}, draw.Over)
+	return nil
+}
+
+var colorNames = map[string]color.RGBA{
+	"":      {A: 0xff},
+	"black": {A: 0xff},
+	"white": {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"red":   {R: 0xff, A: 0xff},
+	"green": {G: 0x80, A: 0xff},
+	"blue":  {B: 0xff, A: 0xff},
+	"gray":  {R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+}
+
+// parseColor understands a few color names and hex colors
+// written as #rrggbb or 0xrrggbb. Pads default to black.
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := colorNames[s]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "#"), "0x")
+	if len(hex) != 6 {
+		return color.RGBA{}, ErrMalformedColor
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrMalformedColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
@@ -0,0 +1,99 @@
+package preview_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+	"github.com/gifs/gifs-go/preview"
+)
+
+var (
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black = color.RGBA{0, 0, 0, 0xff}
+	red   = color.RGBA{0xff, 0, 0, 0xff}
+)
+
+func filled(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRender(t *testing.T) {
+	frame := filled(40, 20, white)
+	frame.SetRGBA(0, 0, red)
+
+	req := &gifs.Request{
+		Crop: gifs.CropAspect(1, 1),
+		Effects: &gifs.Effects{
+			Pad:  []*gifs.Pad{gifs.PadAspect(2, 1, "black")},
+			Flip: []*gifs.Flip{{Vertical: true}},
+			Invert: []*gifs.Invert{
+				{Section: &gifs.Section{X: "0", Y: "0", Width: 5, Height: 5}},
+				{Section: &gifs.Section{X: "0", Y: "10"}, Timeline: &gifs.Timeline{Start: 5, End: 6}},
+			},
+			Overlay: []*gifs.Overlay{
+				{X: gifs.FromRight(0), Y: gifs.FromBottom(0), Source: "logo.png"},
+			},
+		},
+	}
+	opts := &preview.Options{
+		Time: 1,
+		Overlays: func(source string) (image.Image, error) {
+			return filled(4, 4, red), nil
+		},
+	}
+
+	img, err := preview.Render(frame, req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 20x20 crop is padded to 40x20 and centered.
+	if want, got := image.Rect(0, 0, 40, 20), img.Bounds(); want != got {
+		t.Fatalf("bounds: want %v, got %v", want, got)
+	}
+
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{2, 2, white},   // inverted black padding
+		{12, 10, white}, // the cropped frame
+		{38, 18, red},   // the overlay in the bottom right corner
+		{35, 15, black}, // padding next to the overlay
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("(%d, %d): want %v, got %v", tt.x, tt.y, tt.want, got)
+		}
+	}
+}
+
+func TestOverlayNeedsImages(t *testing.T) {
+	effects := &gifs.Effects{Overlay: []*gifs.Overlay{{Source: "logo.png"}}}
+	if _, err := preview.Apply(filled(4, 4, white), effects, nil); err == nil {
+		t.Errorf("expected an error without Options.Overlays")
+	}
+}
+
+func TestApplySkipsNil(t *testing.T) {
+	effects := &gifs.Effects{
+		Pad:     []*gifs.Pad{nil},
+		Flip:    []*gifs.Flip{nil},
+		Invert:  []*gifs.Invert{nil},
+		Overlay: []*gifs.Overlay{nil},
+	}
+	img, err := preview.Apply(filled(4, 4, white), effects, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.RGBAAt(0, 0); got != white {
+		t.Errorf("want the frame as is, got %v", got)
+	}
+}
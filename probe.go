@@ -0,0 +1,128 @@
+package gifs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTrimOutOfRange    = errors.New("trim is out of the media's duration")
+	ErrSourceUnreachable = errors.New("the API could not fetch the source")
+)
+
+// SourceInfo describes a source's media as seen by the API
+// before it is imported.
+type SourceInfo struct {
+	URL string `json:"source,omitempty"`
+
+	// Reachable is false if the API could not fetch the source.
+	Reachable bool `json:"reachable,omitempty"`
+
+	// Duration is in seconds, as are the bounds of Trim.
+	Duration float64 `json:"duration,omitempty"`
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	FPS      float64 `json:"fps,omitempty"`
+	HasAudio bool    `json:"has_audio,omitempty"`
+
+	ContentType string   `json:"content_type,omitempty"`
+	Provider    Provider `json:"provider,omitempty"`
+}
+
+// Probe asks the API for the metadata of the media at url,
+// without importing it. The result can be used to check a
+// Request's Trim and Crop with Validate before importing.
+func (g *Client) Probe(ctx context.Context, url string) (*SourceInfo, error) {
+	if url == "" {
+		return nil, ErrExpectingAtLeastOneSource
+	}
+
+	info := new(SourceInfo)
+	body := map[string]string{"source": url}
+	if err := g.doJSON(ctx, "POST", g.endpoint(probeEndpointPath), body, info); err != nil {
+		return nil, err
+	}
+	if info.URL == "" {
+		info.URL = url
+	}
+	if info.Provider == ProviderUnknown {
+		if src, err := ParseSource(url); err == nil {
+			info.Provider = src.Provider
+		}
+	}
+	return info, nil
+}
+
+// Validate checks that the source described by info is reachable
+// and that the request's Trim and Crop fit in it. Checks that need
+// a dimension or duration that info lacks are skipped.
+func (p *Request) Validate(info *SourceInfo) error {
+	if p == nil || info == nil {
+		return ErrNilParamDereference
+	}
+	if !info.Reachable {
+		return fmt.Errorf("%w: %s", ErrSourceUnreachable, info.URL)
+	}
+
+	if trim := p.Trim; trim != nil {
+		if trim.Start < 0 || (trim.End != 0 && trim.End <= trim.Start) {
+			return fmt.Errorf("%v: start %vs, end %vs", ErrTrimOutOfRange, trim.Start, trim.End)
+		}
+		if d := info.Duration; d > 0 && (trim.Start >= d || trim.End > d) {
+			return fmt.Errorf("%v: start %vs, end %vs of %vs", ErrTrimOutOfRange, trim.Start, trim.End, d)
+		}
+	}
+
+	if p.Crop != nil && info.Width > 0 && info.Height > 0 {
+		if _, err := p.Crop.Resolve(info.Width, info.Height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve replaces the request's relative Crop and Pads, such as those
+// of CropInset or PadAspect, by their values in pixels for the source
+// described by info, e.g as returned by Client.Probe. The API only takes
+// pixels so requests with relative crops or pads fail to be sent until
+// resolved. Pads are resolved against the dimensions after cropping.
+func (p *Request) Resolve(info *SourceInfo) error {
+	if p == nil || info == nil {
+		return ErrNilParamDereference
+	}
+
+	width, height := info.Width, info.Height
+	if p.Crop != nil {
+		crop := p.Crop
+		if crop.relative() {
+			var err error
+			if crop, err = crop.Resolve(width, height); err != nil {
+				return err
+			}
+		}
+		if crop.Width > 0 && crop.Height > 0 {
+			width, height = int(crop.Width), int(crop.Height)
+		}
+		p.Crop = crop
+	}
+
+	if p.Effects == nil {
+		return nil
+	}
+	pads := make([]*Pad, len(p.Effects.Pad))
+	for i, pad := range p.Effects.Pad {
+		if pad != nil && pad.relative() {
+			var err error
+			if pad, err = pad.Resolve(width, height); err != nil {
+				return err
+			}
+		}
+		pads[i] = pad
+	}
+	// The effects may be shared with other requests e.g by a Preset.
+	effects := *p.Effects
+	effects.Pad = pads
+	p.Effects = &effects
+	return nil
+}
@@ -0,0 +1,85 @@
+package gifs_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestProbe(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		if want, got := "/media/probe", r.URL.Path; want != got {
+			t.Errorf("Path: want %q, got %q", want, got)
+		}
+		return jsonResponse(200, `{"success": {
+			"reachable": true, "duration": 30.5, "width": 640, "height": 360,
+			"fps": 29.97, "has_audio": true, "content_type": "video/mp4", "provider": "direct"
+		}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	info, err := c.Probe(context.Background(), "https://example.com/clip.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gifs.SourceInfo{
+		URL:         "https://example.com/clip.mp4",
+		Reachable:   true,
+		Duration:    30.5,
+		Width:       640,
+		Height:      360,
+		FPS:         29.97,
+		HasAudio:    true,
+		ContentType: "video/mp4",
+		Provider:    gifs.ProviderDirect,
+	}
+	if *info != want {
+		t.Errorf("want %+v, got %+v", want, *info)
+	}
+
+	tests := []struct {
+		req     gifs.Request
+		wantErr bool
+	}{
+		{gifs.Request{Trim: &gifs.Trim{Start: 4.5, End: 19.5}, Crop: gifs.CropAspect(1, 1)}, false},
+		{gifs.Request{Trim: &gifs.Trim{Start: 10, End: 40}}, true},
+		{gifs.Request{Trim: &gifs.Trim{Start: 10, End: 5}}, true},
+		{gifs.Request{Crop: &gifs.Crop{X: 40, Y: 10, Width: 640, Height: 200}}, true},
+	}
+	for i, tt := range tests {
+		err := tt.req.Validate(info)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("#%d: wantErr %v, got err %v", i, tt.wantErr, err)
+		}
+	}
+
+	unreachable := *info
+	unreachable.Reachable = false
+	if err := new(gifs.Request).Validate(&unreachable); !errors.Is(err, gifs.ErrSourceUnreachable) {
+		t.Errorf("unreachable: want %v, got %v", gifs.ErrSourceUnreachable, err)
+	}
+}
+
+func TestProbeError(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(404, `{"errors": "source not found"}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+	if _, err := c.Probe(context.Background(), "https://example.com/missing.mp4"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,112 @@
+package gifs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// String describes the request as its JSON body, with
+// the APIKey redacted, so that it is safe to log.
+func (p Request) String() string {
+	body, err := json.Marshal(struct {
+		Request
+		APIKey string `json:"api_key,omitempty"`
+	}{p, p.redactedKey()})
+	if err != nil {
+		return fmt.Sprintf("Request{source: %q, err: %v}", p.URL, err)
+	}
+	return "Request" + string(body)
+}
+
+// GoString keeps %#v from printing the APIKey.
+func (p Request) GoString() string {
+	return p.String()
+}
+
+// LogValue logs the request's main fields with the APIKey redacted.
+func (p Request) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("source", p.URL),
+		slog.String("title", p.Title),
+		slog.Any("tags", p.Tags),
+		slog.String("caller", p.CreatedFrom),
+	}
+	if key := p.redactedKey(); key != "" {
+		attrs = append(attrs, slog.String("api_key", key))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (p Request) redactedKey() string {
+	if p.APIKey == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (g *Client) String() string {
+	if g == nil {
+		return "Client(nil)"
+	}
+	return fmt.Sprintf("Client{baseURL: %q, credentials: %s, signing: %v}", g.endpoint(""), g.credentialsString(), g.signing)
+}
+
+func (g *Client) GoString() string {
+	return g.String()
+}
+
+func (g *Client) LogValue() slog.Value {
+	if g == nil {
+		return slog.StringValue(g.String())
+	}
+	return slog.GroupValue(
+		slog.String("base_url", g.endpoint("")),
+		slog.String("credentials", g.credentialsString()),
+		slog.Bool("signing", g.signing),
+	)
+}
+
+// credentialsString describes the Client's credentials by their String
+// method if they have one, or else only by their type as their fields
+// may hold keys.
+func (g *Client) credentialsString() string {
+	switch creds := g.creds.(type) {
+	case nil:
+		return "none"
+	case fmt.Stringer:
+		return creds.String()
+	default:
+		return fmt.Sprintf("%T", creds)
+	}
+}
+
+// signRequest authenticates r without sending key by adding the headers:
+//
+//	Gifs-Key-Id:         hex SHA-256 of the key, for the API to look it up
+//	Gifs-Timestamp:      the Unix time of signing, bounding replays
+//	Gifs-Content-Sha256: hex SHA-256 of the body
+//	Gifs-Signature:      hex HMAC-SHA256 keyed by the key of the lines
+//	                     method, request URI, timestamp and body hash
+func signRequest(r *http.Request, body []byte, key string, now time.Time) {
+	keyID := sha256.Sum256([]byte(key))
+	bodySum := sha256.Sum256(body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	bodyHash := hex.EncodeToString(bodySum[:])
+
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", r.Method, r.URL.RequestURI(), timestamp, bodyHash)
+
+	r.Header.Set("Gifs-Key-Id", hex.EncodeToString(keyID[:]))
+	r.Header.Set("Gifs-Timestamp", timestamp)
+	r.Header.Set("Gifs-Content-Sha256", bodyHash)
+	r.Header.Set("Gifs-Signature", hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,100 @@
+package gifs_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestRedaction(t *testing.T) {
+	req := &gifs.Request{URL: "https://example.org/a.mp4", APIKey: "s3cr3t"}
+	c, _ := gifs.New(gifs.WithCredentials(gifs.NewTenantKeys(map[string]string{"acme": "s3cr3t"})))
+	static, _ := gifs.New(gifs.WithAPIKey("s3cr3t"))
+
+	var logged bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logged, nil))
+	logger.Info("import", "req", req, "client", c)
+
+	outputs := []string{
+		fmt.Sprint(req), fmt.Sprintf("%+v", *req), fmt.Sprintf("%#v", *req),
+		fmt.Sprint(c), fmt.Sprintf("%#v", static), logged.String(),
+	}
+	for _, out := range outputs {
+		if strings.Contains(out, "s3cr3t") {
+			t.Errorf("key leaked in %s", out)
+		}
+	}
+	if !strings.Contains(outputs[0], "REDACTED") || !strings.Contains(outputs[0], "a.mp4") {
+		t.Errorf("String: got %s", outputs[0])
+	}
+}
+
+type vault struct{ key string }
+
+func (v *vault) APIKey(context.Context) (string, error) {
+	return v.key, nil
+}
+
+func TestRedactCustomCredentials(t *testing.T) {
+	c, _ := gifs.New(gifs.WithCredentials(&vault{"s3cr3t"}))
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("import", "client", c)
+	for _, out := range []string{fmt.Sprint(c), fmt.Sprintf("%#v", c), logged.String()} {
+		if strings.Contains(out, "s3cr3t") || !strings.Contains(out, "vault") {
+			t.Errorf("want the credentials' type only, got %s", out)
+		}
+	}
+
+	var nilClient *gifs.Client
+	logged.Reset()
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("import", "client", nilClient)
+	if !strings.Contains(logged.String(), "Client(nil)") {
+		t.Errorf("nil Client: got %s", logged.String())
+	}
+}
+
+func TestHMACSigning(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	hc := &http.Client{Transport: transport(func(r *http.Request) (*http.Response, error) {
+		got = r
+		body, _ = ioutil.ReadAll(r.Body)
+		return jsonResponse(http.StatusOK, `{"success":{"page":"https://gifs.com/gif/Z4Wrp2"}}`), nil
+	})}
+	c, _ := gifs.New(gifs.WithAPIKey("s3cr3t"), gifs.WithHTTPClient(hc), gifs.WithHMACSigning())
+	if _, err := c.Import(&gifs.Request{URL: "https://example.org/a.mp4"}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if key := got.Header.Get("Gifs-Api-Key"); key != "" {
+		t.Errorf("the key %q was sent", key)
+	}
+	if bytes.Contains(body, []byte("s3cr3t")) {
+		t.Errorf("the key was sent in the body %s", body)
+	}
+
+	bodySum := sha256.Sum256(body)
+	bodyHash := hex.EncodeToString(bodySum[:])
+	if want, have := bodyHash, got.Header.Get("Gifs-Content-Sha256"); want != have {
+		t.Errorf("body hash: want %s, got %s", want, have)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", got.Method, got.URL.RequestURI(), got.Header.Get("Gifs-Timestamp"), bodyHash)
+	if want, have := hex.EncodeToString(mac.Sum(nil)), got.Header.Get("Gifs-Signature"); want != have {
+		t.Errorf("signature: want %s, got %s", want, have)
+	}
+	keyID := sha256.Sum256([]byte("s3cr3t"))
+	if want, have := hex.EncodeToString(keyID[:]), got.Header.Get("Gifs-Key-Id"); want != have {
+		t.Errorf("key id: want %s, got %s", want, have)
+	}
+}
@@ -0,0 +1,206 @@
+package gifs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Scheduler limits how many imports and uploads a Client runs at
+// once and picks which queued request goes next when a slot frees.
+//
+// Requests are queued by tenant, as given by TenantFromContext
+// i.e ContextWithTenant or else the request's CreatedFrom. Import,
+// ImportSources and ImportBulk take no context so the tenant of an
+// import is always its CreatedFrom. Slots are
+// shared between tenants by weighted fair queuing, a tenant with
+// weight 2 gets twice the slots of one with weight 1 while both
+// have queued requests, so a large backfill by one tenant only
+// slows down the interactive imports of the others. Within a tenant
+// requests go by Priority, then in the order they were queued.
+//
+// A Scheduler is shared by every concurrent ImportBulk on the
+// Clients created WithScheduler, each of which is still limited by
+// its own ConcurrentImports.
+type Scheduler struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	weights map[string]int
+	queues  map[string]*tenantQueue
+	// vtime is the virtual start time of the last dispatch,
+	// tenants that were idle resume from it rather than
+	// from the credit they would have accumulated. Queues
+	// are forgotten once idle and caught up with it.
+	vtime float64
+	seq   uint64
+}
+
+type tenantQueue struct {
+	tenant  string
+	finish  float64
+	waiters waiterHeap
+}
+
+type schedWaiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	// index is the waiter's position in its queue's heap,
+	// -1 once it has been dispatched.
+	index int
+}
+
+// NewScheduler returns a Scheduler that runs at most
+// slots requests at once, less than 1 means 1.
+func NewScheduler(slots int) *Scheduler {
+	if slots < 1 {
+		slots = 1
+	}
+	return &Scheduler{
+		slots:   slots,
+		weights: make(map[string]int),
+		queues:  make(map[string]*tenantQueue),
+	}
+}
+
+// SetWeight sets the share of slots of tenant relative to other
+// tenants, the default is 1 and weights less than 1 reset it.
+func (s *Scheduler) SetWeight(tenant string, weight int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if weight < 1 {
+		delete(s.weights, tenant)
+		return
+	}
+	s.weights[tenant] = weight
+}
+
+// Queued returns the number of requests waiting for a slot.
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, q := range s.queues {
+		n += len(q.waiters)
+	}
+	return n
+}
+
+// acquire blocks until the request that ctx is for gets a slot,
+// the returned func must be called to give the slot back.
+// A nil Scheduler admits every request at once.
+func (s *Scheduler) acquire(ctx context.Context, priority int) (release func(), err error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	tenant := TenantFromContext(ctx)
+	w := &schedWaiter{priority: priority, ready: make(chan struct{})}
+	s.mu.Lock()
+	s.seq++
+	w.seq = s.seq
+	q := s.queues[tenant]
+	if q == nil {
+		q = &tenantQueue{tenant: tenant}
+		s.queues[tenant] = q
+	}
+	heap.Push(&q.waiters, w)
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.index < 0 {
+		// Dispatched while being cancelled.
+		s.running--
+		s.dispatch()
+	} else {
+		heap.Remove(&q.waiters, w.index)
+	}
+	return nil, ctx.Err()
+}
+
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.dispatch()
+}
+
+// dispatch hands free slots to the waiters of the tenants with
+// the earliest virtual start times. s.mu must be held.
+func (s *Scheduler) dispatch() {
+	for s.running < s.slots {
+		var next *tenantQueue
+		var nextStart float64
+		for tenant, q := range s.queues {
+			if len(q.waiters) == 0 {
+				if q.finish <= s.vtime {
+					delete(s.queues, tenant)
+				}
+				continue
+			}
+			start := q.finish
+			if start < s.vtime {
+				start = s.vtime
+			}
+			if next == nil || start < nextStart ||
+				(start == nextStart && q.waiters[0].seq < next.waiters[0].seq) {
+				next, nextStart = q, start
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		w := heap.Pop(&next.waiters).(*schedWaiter)
+		s.vtime = nextStart
+		next.finish = nextStart + 1/float64(s.weight(next.tenant))
+		s.running++
+		close(w.ready)
+	}
+}
+
+func (s *Scheduler) weight(tenant string) int {
+	if w, ok := s.weights[tenant]; ok {
+		return w
+	}
+	return 1
+}
+
+// waiterHeap orders waiters by priority then by arrival.
+type waiterHeap []*schedWaiter
+
+func (h waiterHeap) Len() int { return len(h) }
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*schedWaiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*h = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,159 @@
+package gifs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+// gatedImports returns an http.Client whose imports each wait to be
+// let through by proceed, recording their sources in the order sent.
+func gatedImports() (*http.Client, chan<- bool, func() []string) {
+	var mu sync.Mutex
+	var order []string
+	proceed := make(chan bool)
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		sent := new(gifs.Request)
+		json.Unmarshal(body, sent)
+		mu.Lock()
+		order = append(order, sent.URL)
+		mu.Unlock()
+		<-proceed
+		return jsonResponse(http.StatusOK, `{"success":{}}`), nil
+	}
+	sent := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), order...)
+	}
+	return &http.Client{Transport: transport(roundTrip)}, proceed, sent
+}
+
+func waitQueued(t *testing.T, s *gifs.Scheduler, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Queued() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued: want %d, got %d", n, s.Queued())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerFairness(t *testing.T) {
+	hc, proceed, sent := gatedImports()
+	s := gifs.NewScheduler(1)
+	c, _ := gifs.New(gifs.WithHTTPClient(hc), gifs.WithScheduler(s))
+
+	var wg sync.WaitGroup
+	importAll := func(tenant string, n int) {
+		reqs := make([]*gifs.Request, n)
+		for i := range reqs {
+			reqs[i] = &gifs.Request{URL: fmt.Sprintf("%s-%d", tenant, i), CreatedFrom: tenant}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ImportBulk(&gifs.BulkImportRequest{ConcurrentImports: uint(n), Requests: reqs})
+		}()
+	}
+
+	importAll("backfill", 20)
+	waitQueued(t, s, 19)
+	importAll("interactive", 2)
+	waitQueued(t, s, 21)
+	for i := 0; i < 22; i++ {
+		proceed <- true
+	}
+	wg.Wait()
+
+	order := sent()
+	served := 0
+	for _, source := range order[:4] {
+		if strings.HasPrefix(source, "interactive") {
+			served++
+		}
+	}
+	if served != 2 {
+		t.Errorf("interactive imports were starved: %v", order)
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	hc, proceed, sent := gatedImports()
+	s := gifs.NewScheduler(1)
+	c, _ := gifs.New(gifs.WithHTTPClient(hc), gifs.WithScheduler(s))
+
+	var wg sync.WaitGroup
+	importOne := func(req *gifs.Request) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Import(req)
+		}()
+	}
+
+	importOne(&gifs.Request{URL: "running"})
+	for len(sent()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		importOne(&gifs.Request{URL: fmt.Sprintf("low-%d", i)})
+	}
+	waitQueued(t, s, 3)
+	importOne(&gifs.Request{URL: "urgent", Priority: 10})
+	waitQueued(t, s, 4)
+	for i := 0; i < 5; i++ {
+		proceed <- true
+	}
+	wg.Wait()
+
+	if want, got := "urgent", sent()[1]; want != got {
+		t.Errorf("after the running import: want %q, got %q in %v", want, got, sent())
+	}
+}
+
+func TestSchedulerPriorityInBulk(t *testing.T) {
+	hc, proceed, sent := gatedImports()
+	s := gifs.NewScheduler(1)
+	c, _ := gifs.New(gifs.WithHTTPClient(hc), gifs.WithScheduler(s))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.Import(&gifs.Request{URL: "running"})
+	}()
+	for len(sent()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// More requests than the default ConcurrentImports, the
+	// urgent one last.
+	reqs := make([]*gifs.Request, 20)
+	for i := range reqs {
+		reqs[i] = &gifs.Request{URL: fmt.Sprintf("u%d", i)}
+	}
+	reqs[19].Priority = 100
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.ImportBulk(&gifs.BulkImportRequest{Requests: reqs})
+	}()
+	waitQueued(t, s, 10)
+	for i := 0; i < 21; i++ {
+		proceed <- true
+	}
+	wg.Wait()
+
+	if want, got := "u19", sent()[1]; want != got {
+		t.Errorf("after the running import: want %q, got %q in %v", want, got, sent())
+	}
+}
@@ -0,0 +1,94 @@
+package gifs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+const searchEndpointPath = "/media/search"
+
+type SearchSort string
+
+const (
+	SortRelevance SearchSort = "relevance"
+	SortNewest    SearchSort = "newest"
+	SortPopular   SearchSort = "popular"
+)
+
+type SearchOptions struct {
+	// Tags if set only matches media with all of these tags.
+	Tags []string
+
+	// IncludeNSFW lets Not-Safe-For-Work media be matched, otherwise
+	// the API is asked to leave it out and any NSFW hit it still
+	// returns is dropped.
+	IncludeNSFW bool
+
+	// Sort defaults to SortRelevance.
+	Sort SearchSort
+
+	// Cursor is the NextCursor of the previous page of
+	// results, leave it empty for the first page.
+	Cursor string
+
+	// PageSize is the number of hits per page,
+	// the API picks a default if it is unset.
+	PageSize int
+}
+
+// SearchHit is media that matched a search. It embeds
+// a Response so that its files can be looked up by File.
+type SearchHit struct {
+	Response
+
+	ID    string   `json:"id,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	NSFW  bool     `json:"nsfw,omitempty"`
+}
+
+type SearchResults struct {
+	Hits []*SearchHit `json:"hits,omitempty"`
+
+	// Total is the API's estimate of the number of hits.
+	Total int64 `json:"total,omitempty"`
+
+	// NextCursor if non-empty fetches the next page
+	// when set as SearchOptions.Cursor.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Search looks up media on gifs.com by its title and tags.
+func (g *Client) Search(ctx context.Context, query string, opts SearchOptions) (*SearchResults, error) {
+	values := url.Values{"q": {query}}
+	for _, tag := range opts.Tags {
+		values.Add("tag", tag)
+	}
+	values.Set("nsfw", strconv.FormatBool(opts.IncludeNSFW))
+	if opts.Sort != "" {
+		values.Set("sort", string(opts.Sort))
+	}
+	if opts.Cursor != "" {
+		values.Set("cursor", opts.Cursor)
+	}
+	if opts.PageSize > 0 {
+		values.Set("page_size", strconv.Itoa(opts.PageSize))
+	}
+
+	results := new(SearchResults)
+	uri := g.endpoint(searchEndpointPath) + "?" + values.Encode()
+	if err := g.doJSON(ctx, "GET", uri, nil, results); err != nil {
+		return nil, err
+	}
+	if !opts.IncludeNSFW {
+		hits := results.Hits[:0]
+		for _, hit := range results.Hits {
+			if hit != nil && !hit.NSFW {
+				hits = append(hits, hit)
+			}
+		}
+		results.Hits = hits
+	}
+	return results, nil
+}
@@ -0,0 +1,45 @@
+package gifs_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestSearch(t *testing.T) {
+	roundTrip := func(r *http.Request) (*http.Response, error) {
+		query := r.URL.Query()
+		if r.URL.Path != "/media/search" || query.Get("q") != "dab" || query.Get("sort") != "popular" || query.Get("nsfw") != "false" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if want, got := []string{"migos", "dance"}, query["tag"]; !reflect.DeepEqual(want, got) {
+			t.Errorf("tags: want %v, got %v", want, got)
+		}
+		return jsonResponse(200, `{"success": {"total": 1, "next_cursor": "p2", "hits": [{
+			"id": "Z4Wrp2", "title": "Migos Dab", "tags": ["migos", "dance"],
+			"page": "https://gifs.com/gif/Z4Wrp2", "files": {"mp4": "https://j.gifs.com/Z4Wrp2.mp4"}
+		}, {"id": "Mj9WEQ", "nsfw": true}]}}`), nil
+	}
+	c, _ := gifs.New(gifs.WithHTTPClient(&http.Client{Transport: transport(roundTrip)}))
+
+	results, err := c.Search(context.Background(), "dab", gifs.SearchOptions{
+		Tags: []string{"migos", "dance"},
+		Sort: gifs.SortPopular,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Hits) != 1 || results.NextCursor != "p2" {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	hit := results.Hits[0]
+	if hit.ID != "Z4Wrp2" || hit.Title != "Migos Dab" || hit.Page != "https://gifs.com/gif/Z4Wrp2" {
+		t.Errorf("unexpected hit %+v", hit)
+	}
+	if want, got := "https://j.gifs.com/Z4Wrp2.mp4", hit.File(gifs.MP4); want != got {
+		t.Errorf("File(MP4): want %q, got %q", want, got)
+	}
+}
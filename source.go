@@ -0,0 +1,200 @@
+package gifs
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrMalformedSource = errors.New("malformed source URL")
+
+// Provider identifies the kind of site that hosts a source.
+type Provider string
+
+const (
+	ProviderUnknown  Provider = ""
+	ProviderYouTube  Provider = "youtube"
+	ProviderTwitter  Provider = "twitter"
+	ProviderFacebook Provider = "facebook"
+	ProviderImgur    Provider = "imgur"
+	// ProviderDirect is a plain media file served over HTTP.
+	ProviderDirect Provider = "direct"
+)
+
+// Source is a parsed source URL.
+type Source struct {
+	Provider Provider
+
+	// ID is the provider's identifier for the media e.g
+	// a YouTube video ID or a tweet's status ID.
+	ID string
+
+	// URL is the canonical form of the source such that
+	// all the ways of sharing the same media map to it.
+	URL string
+
+	// Original is the URL as it was passed to ParseSource.
+	Original string
+
+	// Start is the offset in seconds that the shared URL
+	// points to, e.g from YouTube's t=1m30s parameter.
+	Start float64
+}
+
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".webm": true, ".mov": true, ".avi": true, ".mkv": true,
+	".m4v": true, ".flv": true, ".wmv": true, ".mpg": true, ".mpeg": true,
+	".gif": true, ".jpg": true, ".jpeg": true, ".png": true, ".webp": true,
+}
+
+// ParseSource identifies the provider of a source URL, canonicalizes
+// it and extracts the media's ID and the timestamp it links to if any.
+// URLs from unrecognized sites are returned with ProviderUnknown.
+func ParseSource(rawurl string) (*Source, error) {
+	original := rawurl
+	rawurl = strings.TrimSpace(rawurl)
+	if !strings.Contains(rawurl, "://") {
+		rawurl = "https://" + rawurl
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, ErrMalformedSource
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	host := u.Hostname()
+	for _, prefix := range []string{"www.", "m.", "mobile."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	src := &Source{Original: original}
+	switch host {
+	case "youtube.com", "music.youtube.com", "youtube-nocookie.com", "youtu.be":
+		src.Provider = ProviderYouTube
+		switch {
+		case host == "youtu.be" && len(segments) > 0:
+			src.ID = segments[0]
+		case len(segments) == 1 && segments[0] == "watch":
+			src.ID = u.Query().Get("v")
+		case len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "v" || segments[0] == "live"):
+			src.ID = segments[1]
+		}
+		if src.ID != "" {
+			src.URL = "https://www.youtube.com/watch?v=" + url.QueryEscape(src.ID)
+			src.Start = parseTimestamp(u)
+		}
+
+	case "twitter.com", "x.com":
+		src.Provider = ProviderTwitter
+		if len(segments) >= 3 && segments[1] == "status" {
+			src.ID = segments[2]
+			src.URL = "https://twitter.com/" + segments[0] + "/status/" + src.ID
+		}
+
+	case "facebook.com", "fb.watch":
+		src.Provider = ProviderFacebook
+		switch {
+		case host == "fb.watch" && len(segments) > 0:
+			src.ID = segments[0]
+			src.URL = "https://fb.watch/" + src.ID + "/"
+		case len(segments) >= 3 && segments[1] == "videos":
+			src.ID = segments[len(segments)-1]
+			src.URL = "https://www.facebook.com/" + segments[0] + "/videos/" + src.ID + "/"
+		case len(segments) >= 1 && (segments[0] == "watch" || segments[0] == "video.php"):
+			src.ID = u.Query().Get("v")
+			src.URL = "https://www.facebook.com/watch/?v=" + url.QueryEscape(src.ID)
+		}
+		src.Start = parseTimestamp(u)
+
+	case "imgur.com", "i.imgur.com":
+		src.Provider = ProviderImgur
+		switch {
+		case len(segments) == 1:
+			ext := path.Ext(segments[0])
+			src.ID = strings.TrimSuffix(segments[0], ext)
+			// Direct links to the media are kept, only pages are canonicalized.
+			if !mediaExtensions[strings.ToLower(ext)] {
+				src.URL = "https://imgur.com/" + src.ID
+			}
+		case len(segments) == 2 && (segments[0] == "gallery" || segments[0] == "a"):
+			src.ID = segments[1]
+			src.URL = "https://imgur.com/" + segments[0] + "/" + src.ID
+		}
+
+	default:
+		if mediaExtensions[strings.ToLower(path.Ext(u.Path))] {
+			src.Provider = ProviderDirect
+			// Media players accept a #t=start fragment, query
+			// parameters such as t are usually cache busters.
+			src.Start = parseFragmentTimestamp(u)
+		}
+	}
+
+	if src.URL == "" {
+		u.Fragment = ""
+		src.URL = u.String()
+	}
+	return src, nil
+}
+
+// Request returns a Request that imports the source
+// starting at the timestamp that it was shared with.
+func (s *Source) Request() *Request {
+	req := &Request{URL: s.URL}
+	if s.Start > 0 {
+		req.Trim = &Trim{Start: s.Start}
+	}
+	return req
+}
+
+// parseTimestamp looks for a start offset in the t or start
+// query parameters, then in a #t= fragment.
+func parseTimestamp(u *url.URL) float64 {
+	query := u.Query()
+	for _, key := range []string{"t", "start"} {
+		if secs, ok := parseDuration(query.Get(key)); ok {
+			return secs
+		}
+	}
+	return parseFragmentTimestamp(u)
+}
+
+// parseFragmentTimestamp looks for a start offset in a #t= media fragment.
+func parseFragmentTimestamp(u *url.URL) float64 {
+	if strings.HasPrefix(u.Fragment, "t=") {
+		// Only the start of a media fragment e.g "t=10,20" is of interest.
+		start := strings.SplitN(strings.TrimPrefix(u.Fragment, "t="), ",", 2)[0]
+		if secs, ok := parseDuration(start); ok {
+			return secs
+		}
+	}
+	return 0
+}
+
+var timestampRegexp = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+(?:\.\d+)?)s?)?$`)
+
+// parseDuration parses timestamps as shared in URLs
+// e.g "90", "90s", "1m30s" or "1h2m3s".
+func parseDuration(s string) (float64, bool) {
+	m := timestampRegexp.FindStringSubmatch(s)
+	if s == "" || m == nil {
+		return 0, false
+	}
+	var secs float64
+	for i, scale := range []float64{3600, 60, 1} {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, false
+		}
+		secs += v * scale
+	}
+	return secs, true
+}
@@ -0,0 +1,63 @@
+package gifs_test
+
+import (
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		in       string
+		provider gifs.Provider
+		id       string
+		url      string
+		start    float64
+	}{
+		{"youtu.be/jNQXAC9IVRw", gifs.ProviderYouTube, "jNQXAC9IVRw", "https://www.youtube.com/watch?v=jNQXAC9IVRw", 0},
+		{"https://www.youtube.com/watch?v=jNQXAC9IVRw&t=30", gifs.ProviderYouTube, "jNQXAC9IVRw", "https://www.youtube.com/watch?v=jNQXAC9IVRw", 30},
+		{"https://m.youtube.com/watch?v=jNQXAC9IVRw&feature=share&t=1m30s", gifs.ProviderYouTube, "jNQXAC9IVRw", "https://www.youtube.com/watch?v=jNQXAC9IVRw", 90},
+		{"https://youtu.be/jNQXAC9IVRw?t=1h2m3s", gifs.ProviderYouTube, "jNQXAC9IVRw", "https://www.youtube.com/watch?v=jNQXAC9IVRw", 3723},
+		{"https://www.youtube.com/shorts/_gB2iWln0ls", gifs.ProviderYouTube, "_gB2iWln0ls", "https://www.youtube.com/watch?v=_gB2iWln0ls", 0},
+		{"https://mobile.twitter.com/kanyewest/status/726835785274646529?s=20", gifs.ProviderTwitter, "726835785274646529", "https://twitter.com/kanyewest/status/726835785274646529", 0},
+		{"https://x.com/Nike/status/764611634711105537", gifs.ProviderTwitter, "764611634711105537", "https://twitter.com/Nike/status/764611634711105537", 0},
+		{"https://www.facebook.com/Pagefanclub/videos/1070860819621603/", gifs.ProviderFacebook, "1070860819621603", "https://www.facebook.com/Pagefanclub/videos/1070860819621603/", 0},
+		{"https://i.imgur.com/AbCd123.gifv", gifs.ProviderImgur, "AbCd123", "https://imgur.com/AbCd123", 0},
+		{"https://i.imgur.com/AbCd123.gif", gifs.ProviderImgur, "AbCd123", "https://i.imgur.com/AbCd123.gif", 0},
+		{"https://i.imgur.com/AbCd123.mp4", gifs.ProviderImgur, "AbCd123", "https://i.imgur.com/AbCd123.mp4", 0},
+		{"https://imgur.com/gallery/XyZ987", gifs.ProviderImgur, "XyZ987", "https://imgur.com/gallery/XyZ987", 0},
+		{"https://j.gifs.com/PNoDGy.gif", gifs.ProviderDirect, "", "https://j.gifs.com/PNoDGy.gif", 0},
+		{"HTTP://Video.WebmFiles.org/elephants-dream.webm#t=12.5", gifs.ProviderDirect, "", "http://video.webmfiles.org/elephants-dream.webm", 12.5},
+		{"https://cdn.example.com/clip.mp4?t=1697040000", gifs.ProviderDirect, "", "https://cdn.example.com/clip.mp4?t=1697040000", 0},
+		{"https://example.com/watch/123", gifs.ProviderUnknown, "", "https://example.com/watch/123", 0},
+	}
+
+	for _, tt := range tests {
+		src, err := gifs.ParseSource(tt.in)
+		if err != nil {
+			t.Errorf("%q: want nil, got err %v", tt.in, err)
+			continue
+		}
+		if src.Provider != tt.provider || src.ID != tt.id || src.URL != tt.url || src.Start != tt.start {
+			t.Errorf("%q: want {%s %s %s %v}, got {%s %s %s %v}", tt.in,
+				tt.provider, tt.id, tt.url, tt.start, src.Provider, src.ID, src.URL, src.Start)
+		}
+	}
+
+	for _, in := range []string{"", "ftp://example.com/clip.mp4", "https://"} {
+		if _, err := gifs.ParseSource(in); err == nil {
+			t.Errorf("%q: want an error", in)
+		}
+	}
+}
+
+func TestSourceRequest(t *testing.T) {
+	src, err := gifs.ParseSource("https://youtu.be/jNQXAC9IVRw?t=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := src.Request()
+	if req.Trim == nil || req.Trim.Start != 4 {
+		t.Errorf("want a trim starting at 4s, got %+v", req.Trim)
+	}
+}
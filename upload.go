@@ -0,0 +1,274 @@
+package gifs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var ErrNoMedia = errors.New("no media set, expecting a call to SetMedia before uploading")
+
+const (
+	uploadsEndpointPath = "/media/uploads"
+
+	DefaultChunkSize        = 8 << 20
+	defaultMaxChunkAttempts = 3
+	chunkRetryBackoff       = 500 * time.Millisecond
+)
+
+// UploadSession tracks the progress of a chunked upload on the API.
+// Its ID is all that is needed to resume an interrupted upload.
+type UploadSession struct {
+	ID string `json:"id,omitempty"`
+
+	// Offset is the number of bytes that the API has received.
+	Offset    int64 `json:"offset,omitempty"`
+	ChunkSize int64 `json:"chunk_size,omitempty"`
+}
+
+type UploadOptions struct {
+	// ChunkSize is the number of bytes sent per request,
+	// DefaultChunkSize is used if it is unset.
+	ChunkSize int64
+
+	// MaxChunkAttempts is the number of times a chunk is
+	// sent before giving up on the upload. Defaults to 3.
+	MaxChunkAttempts int
+
+	// MaxSize if set is the largest media in bytes that may be
+	// uploaded. Larger media is rejected before the upload starts,
+	// media of unknown size such as that set by SetMedia is copied
+	// to a temporary file first to learn its size.
+	MaxSize int64
+
+	// Progress if set is invoked after every chunk with the number
+	// of bytes uploaded so far and the media's size, -1 if unknown.
+	Progress func(uploaded, size int64)
+
+	// OnSession if set is invoked once the session is created
+	// and after every chunk that the API acknowledges, so that
+	// the session's ID can be persisted to resume it later.
+	OnSession func(*UploadSession)
+}
+
+func (uo *UploadOptions) chunkSize() int64 {
+	if uo != nil && uo.ChunkSize > 0 {
+		return uo.ChunkSize
+	}
+	return DefaultChunkSize
+}
+
+func (uo *UploadOptions) maxChunkAttempts() int {
+	if uo != nil && uo.MaxChunkAttempts > 0 {
+		return uo.MaxChunkAttempts
+	}
+	return defaultMaxChunkAttempts
+}
+
+func (uo *UploadOptions) maxSize() int64 {
+	if uo != nil {
+		return uo.MaxSize
+	}
+	return 0
+}
+
+func (uo *UploadOptions) progress(uploaded, size int64) {
+	if uo != nil && uo.Progress != nil {
+		uo.Progress(uploaded, size)
+	}
+}
+
+func (uo *UploadOptions) onSession(s *UploadSession) {
+	if uo != nil && uo.OnSession != nil {
+		copied := *s
+		uo.OnSession(&copied)
+	}
+}
+
+// Upload sends the media set by SetMedia, SetMediaFile or SetMediaWithInfo
+// to the API in chunks, each verified by its SHA-256 checksum. If the upload
+// is interrupted, it can be continued by ResumeUpload with the session's ID
+// as reported to UploadOptions.OnSession.
+func (g *Client) Upload(ctx context.Context, req *Request, opts *UploadOptions) (*Response, error) {
+	if req == nil {
+		return nil, ErrNilParamDereference
+	}
+	// All of the upload's requests use the same key.
+	ctx, _, err := g.withAPIKey(contextWithRequest(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	info := req.info()
+	if max := opts.maxSize(); max > 0 && info.size > max {
+		return nil, fmt.Errorf("%w: %d bytes, maximum is %d", ErrMediaTooLarge, info.size, max)
+	}
+	media, closer, err := req.openMedia()
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+	media, cleanup, err := checkUpload(media, &info, opts.maxSize())
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := g.prepareRequest(req).inPixels()
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		*Request
+		ChunkSize   int64  `json:"chunk_size,omitempty"`
+		FileName    string `json:"filename,omitempty"`
+		Size        int64  `json:"size,omitempty"`
+		ContentType string `json:"content_type,omitempty"`
+	}{sent, opts.chunkSize(), info.name, info.size, info.contentType}
+	if body.Size < 0 {
+		body.Size = 0
+	}
+	session := new(UploadSession)
+	if err := g.doJSON(ctx, "POST", g.endpoint(uploadsEndpointPath), body, session); err != nil {
+		return nil, err
+	}
+	if session.ID == "" {
+		return nil, errIllogicalState
+	}
+	opts.onSession(session)
+
+	return g.sendChunks(ctx, session, media, info.size, opts)
+}
+
+// ResumeUpload continues the upload of session sessionID from where
+// the API left off. req must hold the same media as it did when the
+// upload was started: the bytes already received are skipped over,
+// by seeking if the media is an io.Seeker.
+func (g *Client) ResumeUpload(ctx context.Context, sessionID string, req *Request, opts *UploadOptions) (*Response, error) {
+	if req == nil {
+		return nil, ErrNilParamDereference
+	}
+	ctx, _, err := g.withAPIKey(contextWithRequest(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	info := req.info()
+	media, closer, err := req.openMedia()
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	session := new(UploadSession)
+	if err := g.doJSON(ctx, "GET", g.sessionURL(sessionID, ""), nil, session); err != nil {
+		return nil, err
+	}
+	session.ID = sessionID
+
+	if seeker, ok := media.(io.Seeker); ok {
+		if _, err := seeker.Seek(session.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(ioutil.Discard, media, session.Offset); err != nil {
+		return nil, fmt.Errorf("skipping the %d uploaded bytes: %v", session.Offset, err)
+	}
+
+	return g.sendChunks(ctx, session, media, info.size, opts)
+}
+
+// info returns what is known about the request's media.
+func (p *Request) info() mediaInfo {
+	if p.mediaInfo == nil {
+		return mediaInfo{size: -1}
+	}
+	return *p.mediaInfo
+}
+
+func (g *Client) sessionURL(sessionID, suffix string) string {
+	return g.endpoint(uploadsEndpointPath + "/" + url.PathEscape(sessionID) + suffix)
+}
+
+func (g *Client) sendChunks(ctx context.Context, session *UploadSession, media io.Reader, size int64, opts *UploadOptions) (*Response, error) {
+	chunkSize := opts.chunkSize()
+	if session.ChunkSize > 0 {
+		// The session's chunk size wins when resuming.
+		chunkSize = session.ChunkSize
+	}
+	session.ChunkSize = chunkSize
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := io.ReadFull(media, buf)
+		if max := opts.maxSize(); max > 0 && session.Offset+int64(n) > max {
+			return nil, fmt.Errorf("%w: maximum is %d bytes", ErrMediaTooLarge, max)
+		}
+		if n > 0 {
+			if err := g.sendChunk(ctx, session, buf[:n], opts.maxChunkAttempts()); err != nil {
+				return nil, err
+			}
+			opts.onSession(session)
+			opts.progress(session.Offset, size)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+
+	res := new(Response)
+	body := map[string]int64{"size": session.Offset}
+	if err := g.doJSON(ctx, "POST", g.sessionURL(session.ID, "/complete"), body, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// sendChunk sends chunk as the bytes from session.Offset onwards, retrying
+// on failure. The API acknowledges a chunk it already has so retrying
+// after a lost reply is safe.
+func (g *Client) sendChunk(ctx context.Context, session *UploadSession, chunk []byte, attempts int) error {
+	sum := sha256.Sum256(chunk)
+	headers := http.Header{
+		"Content-Type":      {"application/octet-stream"},
+		"Content-Range":     {fmt.Sprintf("bytes %d-%d/*", session.Offset, session.Offset+int64(len(chunk))-1)},
+		"Gifs-Chunk-Sha256": {hex.EncodeToString(sum[:])},
+	}
+	uri := g.sessionURL(session.ID, "?offset="+fmt.Sprint(session.Offset))
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var res *http.Response
+		if res, err = g.do(ctx, "PUT", uri, chunk, headers); err == nil {
+			ack := new(UploadSession)
+			if err = decodeAPIResponse(res, ack); err == nil {
+				if ack.Offset != session.Offset+int64(len(chunk)) {
+					return fmt.Errorf("chunk at offset %d: API acknowledged offset %d", session.Offset, ack.Offset)
+				}
+				session.Offset = ack.Offset
+				return nil
+			}
+		}
+		debugLogPrintf("session: %s chunk at offset %d attempt %d err: %v", session.ID, session.Offset, attempt, err)
+
+		if attempt < attempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * chunkRetryBackoff):
+			}
+		}
+	}
+	return err
+}
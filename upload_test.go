@@ -0,0 +1,374 @@
+package gifs_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	gifs "github.com/gifs/gifs-go"
+)
+
+// fakeUploadServer implements the chunked upload protocol,
+// failing chunks as told by failAt.
+type fakeUploadServer struct {
+	mu       sync.Mutex
+	sessions map[string][]byte
+	created  map[string]map[string]interface{}
+	// failAt maps chunk offsets to the number of times to fail them.
+	failAt map[int64]int
+}
+
+func newFakeUploadServer() *fakeUploadServer {
+	return &fakeUploadServer{
+		sessions: make(map[string][]byte),
+		created:  make(map[string]map[string]interface{}),
+		failAt:   make(map[int64]int),
+	}
+}
+
+func reply(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	key := "success"
+	if status >= 300 {
+		key = "errors"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{key: v})
+}
+
+func (fs *fakeUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/media/uploads")
+	switch {
+	case r.Method == "POST" && path == "":
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		id := fmt.Sprintf("session-%d", len(fs.sessions)+1)
+		fs.sessions[id] = nil
+		fs.created[id] = body
+		reply(w, 200, map[string]interface{}{"id": id, "chunk_size": body["chunk_size"]})
+
+	case r.Method == "GET":
+		id := strings.TrimPrefix(path, "/")
+		reply(w, 200, map[string]interface{}{"id": id, "offset": len(fs.sessions[id])})
+
+	case r.Method == "PUT":
+		id := strings.TrimPrefix(path, "/")
+		offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		chunk, _ := ioutil.ReadAll(r.Body)
+		if fs.failAt[offset] > 0 {
+			fs.failAt[offset]--
+			reply(w, 503, "try again")
+			return
+		}
+		sum := sha256.Sum256(chunk)
+		if hex.EncodeToString(sum[:]) != r.Header.Get("Gifs-Chunk-Sha256") {
+			reply(w, 400, "checksum mismatch")
+			return
+		}
+		data := fs.sessions[id]
+		if offset > int64(len(data)) {
+			reply(w, 409, "offset is past the received bytes")
+			return
+		}
+		fs.sessions[id] = append(data[:offset], chunk...)
+		reply(w, 200, map[string]interface{}{"id": id, "offset": len(fs.sessions[id])})
+
+	case r.Method == "POST" && strings.HasSuffix(path, "/complete"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/complete")
+		reply(w, 200, map[string]interface{}{
+			"page":  "https://gifs.com/gif/" + id,
+			"files": map[string]string{"mp4": "https://j.gifs.com/" + id + ".mp4"},
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUploadRetriesChunks(t *testing.T) {
+	fs := newFakeUploadServer()
+	fs.failAt[10] = 1
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	media := append([]byte("\x00\x00\x00\x18ftypisom"), "a recording that is uploaded in chunks"...)
+	req := &gifs.Request{Title: "Keynote"}
+	req.SetMedia(bytes.NewReader(media))
+
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+	res, err := c.Upload(context.Background(), req, &gifs.UploadOptions{ChunkSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "https://gifs.com/gif/session-1", res.Page; want != got {
+		t.Errorf("Page: want %q, got %q", want, got)
+	}
+	if want, got := string(media), string(fs.sessions["session-1"]); want != got {
+		t.Errorf("uploaded: want %q, got %q", want, got)
+	}
+	if want, got := "Keynote", fs.created["session-1"]["title"]; want != got {
+		t.Errorf("Title: want %q, got %q", want, got)
+	}
+}
+
+func TestResumeUpload(t *testing.T) {
+	fs := newFakeUploadServer()
+	fs.failAt[20] = 1
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	media := append([]byte("\x00\x00\x00\x18ftypisom"), "a recording from unreliable conference wifi"...)
+	req := new(gifs.Request)
+	req.SetMedia(bytes.NewReader(media))
+
+	var sessions []gifs.UploadSession
+	opts := &gifs.UploadOptions{
+		ChunkSize:        10,
+		MaxChunkAttempts: 1,
+		OnSession: func(s *gifs.UploadSession) {
+			sessions = append(sessions, *s)
+		},
+	}
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+	if _, err := c.Upload(context.Background(), req, opts); err == nil {
+		t.Fatalf("expected the upload to fail")
+	}
+	last := sessions[len(sessions)-1]
+	if want, got := int64(20), last.Offset; want != got {
+		t.Fatalf("Offset: want %d, got %d", want, got)
+	}
+
+	// Resume with a reader that can't seek, as from a pipe.
+	req.SetMedia(struct{ io.Reader }{bytes.NewReader(media)})
+	res, err := c.ResumeUpload(context.Background(), last.ID, req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Page == "" {
+		t.Errorf("expected a non-empty page")
+	}
+	if want, got := string(media), string(fs.sessions[last.ID]); want != got {
+		t.Errorf("uploaded: want %q, got %q", want, got)
+	}
+}
+
+func TestUploadChecksAnyMedia(t *testing.T) {
+	fs := newFakeUploadServer()
+	server := httptest.NewServer(fs)
+	defer server.Close()
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+
+	// Readers set by SetMedia are of unknown size and type.
+	req := new(gifs.Request)
+	req.SetMedia(strings.NewReader("<!DOCTYPE html><html>not a video</html>"))
+	if _, err := c.Upload(context.Background(), req, nil); !errors.Is(err, gifs.ErrNotMedia) {
+		t.Errorf("html: want %v, got %v", gifs.ErrNotMedia, err)
+	}
+
+	mp4 := append([]byte("\x00\x00\x00\x18ftypisom"), make([]byte, 64)...)
+	req.SetMedia(bytes.NewReader(mp4))
+	if _, err := c.Upload(context.Background(), req, &gifs.UploadOptions{MaxSize: 32}); !errors.Is(err, gifs.ErrMediaTooLarge) {
+		t.Errorf("unknown size: want %v, got %v", gifs.ErrMediaTooLarge, err)
+	}
+	req.SetMediaWithInfo(bytes.NewReader(mp4), "clip.mp4", -1, "")
+	if _, err := c.Upload(context.Background(), req, &gifs.UploadOptions{MaxSize: 32}); !errors.Is(err, gifs.ErrMediaTooLarge) {
+		t.Errorf("declared unknown size: want %v, got %v", gifs.ErrMediaTooLarge, err)
+	}
+	if len(fs.sessions) != 0 {
+		t.Errorf("expected the uploads to be rejected before they started")
+	}
+
+	req.SetMedia(bytes.NewReader(mp4))
+	if _, err := c.Upload(context.Background(), req, &gifs.UploadOptions{MaxSize: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	created := fs.created["session-1"]
+	if created["content_type"] != "video/mp4" || created["size"] != float64(len(mp4)) {
+		t.Errorf("unexpected session %v", created)
+	}
+	if want, got := string(mp4), string(fs.sessions["session-1"]); want != got {
+		t.Errorf("uploaded: want %q, got %q", want, got)
+	}
+}
+
+func TestSetMediaWithInfo(t *testing.T) {
+	mp4 := append([]byte("\x00\x00\x00\x18ftypisom"), make([]byte, 64)...)
+	mov := append([]byte("\x00\x00\x00\x14ftypqt  "), make([]byte, 64)...)
+	zip := append([]byte("PK\x03\x04"), make([]byte, 64)...)
+
+	tests := []struct {
+		name     string
+		media    []byte
+		declared string
+		want     string
+	}{
+		{"clip.mp4", mp4, "", "video/mp4"},
+		{"clip.mov", mov, "", "video/quicktime"},
+		{"clip.mov.zip", zip, "video/quicktime", ""},
+		{"notes.txt", []byte("hello"), "", ""},
+	}
+	for _, tt := range tests {
+		req := new(gifs.Request)
+		// Wrap the reader to hide io.Seeker, the sniffed bytes must still be uploaded.
+		err := req.SetMediaWithInfo(struct{ io.Reader }{bytes.NewReader(tt.media)}, tt.name, int64(len(tt.media)), tt.declared)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: want nil, got err %v", tt.name, err)
+		}
+	}
+}
+
+func TestUploadMediaFile(t *testing.T) {
+	fs := newFakeUploadServer()
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	media := append([]byte("\x00\x00\x00\x18ftypmp42"), bytes.Repeat([]byte("frame"), 10)...)
+	f, err := ioutil.TempFile("", "gifs-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(media)
+	f.Close()
+
+	req := new(gifs.Request)
+	if err := req.SetMediaFile(f.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+	_, err = c.Upload(context.Background(), req, &gifs.UploadOptions{MaxSize: 32})
+	if err == nil {
+		t.Fatalf("expected an upload over MaxSize to fail")
+	}
+	if len(fs.sessions) != 0 {
+		t.Errorf("expected the upload to be rejected before it started")
+	}
+
+	var progress []int64
+	opts := &gifs.UploadOptions{
+		ChunkSize: 16,
+		Progress: func(uploaded, size int64) {
+			if size != int64(len(media)) {
+				t.Errorf("size: want %d, got %d", len(media), size)
+			}
+			progress = append(progress, uploaded)
+		},
+	}
+	if _, err := c.Upload(context.Background(), req, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := []int64{16, 32, 48, 62}, progress; !reflect.DeepEqual(want, got) {
+		t.Errorf("progress: want %v, got %v", want, got)
+	}
+	if want, got := string(media), string(fs.sessions["session-1"]); want != got {
+		t.Errorf("uploaded: want %q, got %q", want, got)
+	}
+	created := fs.created["session-1"]
+	if created["content_type"] != "video/mp4" || created["filename"] != filepath.Base(f.Name()) {
+		t.Errorf("unexpected session %v", created)
+	}
+}
+
+func TestUploadDir(t *testing.T) {
+	fs := newFakeUploadServer()
+	server := httptest.NewServer(fs)
+	defer server.Close()
+
+	root, err := ioutil.TempDir("", "gifs-upload-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	mp4 := append([]byte("\x00\x00\x00\x18ftypisom"), make([]byte, 32)...)
+	files := map[string][]byte{
+		"talks/golang/my_first-talk.mp4": mp4,
+		"talks/intro.mp4":                mp4,
+		"talks/fake.gif":                 []byte("not a gif"),
+		"talks/notes.txt":                []byte("notes"),
+		".cache/skipped.mp4":             mp4,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifestPath := filepath.Join(root, ".manifest.json")
+	c, _ := gifs.New(gifs.WithBaseURL(server.URL))
+	manifest, err := c.UploadDir(context.Background(), root, &gifs.UploadDirOptions{
+		Tags:         []string{"conference"},
+		ManifestPath: manifestPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := new(gifs.Manifest)
+	if err := json.Unmarshal(saved, reloaded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(manifest, reloaded) {
+		t.Errorf("saved manifest differs from the returned one")
+	}
+
+	if want, got := 3, len(manifest.Entries); want != got {
+		t.Fatalf("entries: want %d, got %d", want, got)
+	}
+	entries := make(map[string]*gifs.ManifestEntry)
+	for _, entry := range manifest.Entries {
+		rel, _ := filepath.Rel(root, entry.Path)
+		entries[filepath.ToSlash(rel)] = entry
+	}
+
+	talk := entries["talks/golang/my_first-talk.mp4"]
+	if talk == nil || talk.Page == "" || talk.Error != "" {
+		t.Fatalf("expected the talk to be uploaded, got %+v", talk)
+	}
+	if want, got := "my first talk", talk.Title; want != got {
+		t.Errorf("Title: want %q, got %q", want, got)
+	}
+	if want, got := []string{"talks", "golang", "conference"}, talk.Tags; !reflect.DeepEqual(want, got) {
+		t.Errorf("Tags: want %v, got %v", want, got)
+	}
+	if entry := entries["talks/intro.mp4"]; entry == nil || entry.Page == "" {
+		t.Errorf("expected the intro to be uploaded, got %+v", entry)
+	}
+	if entry := entries["talks/fake.gif"]; entry == nil || entry.Error == "" || entry.Page != "" {
+		t.Errorf("expected the fake gif to be rejected, got %+v", entry)
+	}
+	if want, got := 2, len(fs.sessions); want != got {
+		t.Errorf("sessions: want %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,198 @@
+package gifs
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type UploadDirOptions struct {
+	// Extensions if set restricts the upload to files with these
+	// extensions e.g ".mp4", otherwise all video and image files
+	// that are recognized by their extension are uploaded.
+	Extensions []string
+
+	// Patterns if set restricts the upload to files whose path
+	// relative to the root matches one of these globs, as per
+	// filepath.Match e.g "2017/*/*.mov".
+	Patterns []string
+
+	// Tags are added to the tags derived from each file's folders.
+	Tags []string
+
+	// ConcurrentUploads is the number of files uploaded
+	// in parallel, defaults to the same as for ImportBulk.
+	ConcurrentUploads uint
+
+	// ManifestPath if set is where the manifest is written to as JSON.
+	ManifestPath string
+
+	// Upload holds the options of each file's upload.
+	Upload *UploadOptions
+}
+
+// Manifest records the outcome of uploading a directory tree.
+type Manifest struct {
+	Root    string           `json:"root"`
+	Entries []*ManifestEntry `json:"entries"`
+}
+
+type ManifestEntry struct {
+	// Path is the local path of the uploaded file.
+	Path  string   `json:"path"`
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+
+	Page  string   `json:"page,omitempty"`
+	Files FilesMap `json:"files,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// UploadDir walks the directory tree at root and uploads every matching media
+// file in parallel. Each file's title is derived from its name and its tags
+// from the folders it is in, e.g "talks/golang/my_first_talk.mp4" is titled
+// "my first talk" and tagged "talks" and "golang". Hidden files and folders
+// are skipped.
+//
+// Failures of individual files are recorded in the returned Manifest, the
+// error is only non-nil if the tree could not be walked or the manifest
+// could not be written.
+func (g *Client) UploadDir(ctx context.Context, root string, opts *UploadDirOptions) (*Manifest, error) {
+	if opts == nil {
+		opts = new(UploadDirOptions)
+	}
+
+	manifest := &Manifest{Root: root}
+	var jobs []httpRequestJob
+	var uploaded []*ManifestEntry
+
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(fi.Name(), ".") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !opts.matches(rel) {
+			return nil
+		}
+
+		entry := &ManifestEntry{
+			Path:  path,
+			Title: titleFromFileName(fi.Name()),
+			Tags:  mergeTags(tagsFromDir(filepath.Dir(rel)), opts.Tags),
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+
+		req := &Request{Title: entry.Title, Tags: entry.Tags}
+		if err := req.SetMediaFile(path); err != nil {
+			entry.Error = err.Error()
+			return nil
+		}
+		jobs = append(jobs, httpRequestJob{req: req, typ: uploadRequest, uploadOptions: opts.Upload})
+		uploaded = append(uploaded, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := defaultConcurrentImportsCount
+	if opts.ConcurrentUploads > 0 {
+		concurrency = int(opts.ConcurrentUploads)
+	}
+	responses, err := g.runJobs(ctx, jobs, concurrency, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range responses {
+		if res == nil {
+			continue
+		}
+		entry := uploaded[i]
+		entry.Page, entry.Files = res.Page, res.Files
+		entry.Error = res.Error.Error()
+	}
+
+	if opts.ManifestPath != "" {
+		if err := manifest.WriteFile(opts.ManifestPath); err != nil {
+			return manifest, err
+		}
+	}
+	return manifest, nil
+}
+
+// WriteFile saves the manifest as indented JSON.
+func (m *Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (opts *UploadDirOptions) matches(rel string) bool {
+	ext := strings.ToLower(filepath.Ext(rel))
+	if len(opts.Extensions) == 0 {
+		if !mediaExtensions[ext] {
+			return false
+		}
+	} else {
+		found := false
+		for _, want := range opts.Extensions {
+			if strings.ToLower("."+strings.TrimPrefix(want, ".")) == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(opts.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range opts.Patterns {
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// titleFromFileName turns "my_first-talk.mp4" into "my first talk".
+func titleFromFileName(name string) string {
+	return humanize(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// tagsFromDir returns the folder names in dir,
+// a path relative to the uploaded tree's root.
+func tagsFromDir(dir string) []string {
+	var tags []string
+	for _, folder := range strings.Split(filepath.ToSlash(dir), "/") {
+		if folder != "" && folder != "." {
+			tags = append(tags, humanize(folder))
+		}
+	}
+	return tags
+}
+
+func humanize(name string) string {
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	}), " ")
+}
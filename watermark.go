@@ -0,0 +1,70 @@
+package gifs
+
+// Anchor names a position on the frame relative
+// to which an overlay such as a watermark is placed.
+type Anchor uint
+
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+// Position resolves the anchor into the X and Y positions
+// of an Overlay, keeping margin pixels away from the edges
+// that the anchor touches.
+func (a Anchor) Position(margin float64) (x, y Position) {
+	switch a {
+	case TopLeft, Left, BottomLeft:
+		x = Pixels(margin)
+	case Top, Center, Bottom:
+		x = CenterX()
+	default:
+		x = FromRight(margin)
+	}
+	switch a {
+	case TopLeft, Top, TopRight:
+		y = Pixels(margin)
+	case Left, Center, Right:
+		y = CenterY()
+	default:
+		y = FromBottom(margin)
+	}
+	return x, y
+}
+
+type withWatermark struct {
+	overlay *Overlay
+}
+
+func (ww withWatermark) apply(g *Client) {
+	g.watermark = ww.overlay
+}
+
+// WithDefaultWatermark adds an overlay of source, placed at anchor
+// and margin pixels from the edges, to every request made by the
+// Client unless the request sets SkipWatermark.
+// Opacity is as for Overlay.Opacity, 0 leaves the watermark opaque.
+func WithDefaultWatermark(source string, anchor Anchor, margin int, opacity float32) Option {
+	x, y := anchor.Position(float64(margin))
+	return withWatermark{&Overlay{X: x, Y: y, Source: source, Opacity: opacity}}
+}
+
+// prepareRequest returns the request as it should be sent,
+// merging in the Client's defaults. req itself is left as is
+// so that it can safely be reused or retried.
+func (g *Client) prepareRequest(req *Request) *Request {
+	if req == nil || g.watermark == nil || req.SkipWatermark {
+		return req
+	}
+	prepared := *req
+	watermark := *g.watermark
+	prepared.Effects = mergeEffects(req.Effects, &Effects{Overlay: []*Overlay{&watermark}})
+	return &prepared
+}